@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/apex/httplog"
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/text"
+	"github.com/dustin/go-humanize"
 	"github.com/pkg/browser"
 	"github.com/tj/kingpin"
 
@@ -41,6 +43,8 @@ var client = &http.Client{
 func main() {
 	app := kingpin.New("staticgen", "Static website generator")
 	dir := app.Flag("chdir", "Change working directory").Short('C').Default(".").String()
+	config := app.Flag("config", "Configuration file path, defaults to static.json").Short('c').String()
+	profile := app.Flag("profile", "Configuration profile").Short('p').String()
 
 	log.SetHandler(text.Default)
 
@@ -48,8 +52,9 @@ func main() {
 		return os.Chdir(*dir)
 	})
 
-	generateCmd(app)
-	serveCmd(app)
+	generateCmd(app, config, profile)
+	serveCmd(app, config, profile)
+	configCmd(app, config, profile)
 	versionCmd(app)
 
 	_, err := app.Parse(os.Args[1:])
@@ -59,13 +64,43 @@ func main() {
 }
 
 // generateCmd command.
-func generateCmd(app *kingpin.Application) {
+func generateCmd(app *kingpin.Application, config, profile *string) {
 	cmd := app.Command("generate", "Generate static website").Default()
 	timeout := cmd.Flag("timeout", "Timeout of website generation").Short('t').Default("15m").String()
+	url := cmd.Flag("url", "Target website url").String()
+	dir := cmd.Flag("dir", "Output directory").String()
+	concurrency := cmd.Flag("concurrency", "Number of concurrent requests").String()
+	pages := cmd.Flag("page", "Page to crawl, may be repeated").Strings()
+	format := cmd.Flag("format", "Output format, text, progress or json, defaults to progress when stdout is a terminal").Enum("text", "progress", "json")
+	junit := cmd.Flag("junit", "Output file for a JUnit XML report").String()
+	html := cmd.Flag("report", "Output file for an HTML report, such as report.html").String()
+	output := cmd.Flag("output", "Output file for JSON events, in addition to the text or progress format").Short('o').String()
 	cmd.Action(func(_ *kingpin.ParseContext) error {
 		// generator
 		g := staticgen.Generator{
 			HTTPClient: client,
+			ConfigFile: *config,
+			Profile:    *profile,
+			Overrides:  make(map[string]string),
+			JUnit:      *junit,
+		}
+
+		// overrides
+		if *url != "" {
+			g.Overrides["url"] = *url
+		}
+
+		if *dir != "" {
+			g.Overrides["dir"] = *dir
+		}
+
+		if *concurrency != "" {
+			g.Overrides["concurrency"] = *concurrency
+		}
+
+		if len(*pages) > 0 {
+			b, _ := json.Marshal(*pages)
+			g.Overrides["pages"] = string(b)
 		}
 
 		// parse timeout
@@ -87,32 +122,65 @@ func generateCmd(app *kingpin.Application) {
 		}()
 
 		// reporting
-		events := make(chan staticgen.Event, 1000)
+		if *format == "" {
+			*format = "text"
+			if staticgen.IsTerminal(os.Stdout) {
+				*format = "progress"
+			}
+		}
+
+		switch *format {
+		case "json":
+			if *output == "" {
+				g.Subscribe(&staticgen.JSONReporter{Writer: os.Stdout})
+			}
+		case "progress":
+			g.Subscribe(&staticgen.ProgressReporter{})
+		default:
+			g.Subscribe(&staticgen.Reporter{})
+		}
 
-		var r staticgen.Reporter
-		g.Report(events)
-		done := r.Report(events)
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return fmt.Errorf("creating output: %w", err)
+			}
+			defer f.Close()
+			g.Subscribe(&staticgen.JSONReporter{Writer: f})
+		}
+
+		if *html != "" {
+			f, err := os.Create(*html)
+			if err != nil {
+				return fmt.Errorf("creating report: %w", err)
+			}
+			defer f.Close()
+			g.Subscribe(&staticgen.HTMLReporter{Writer: f})
+		}
 
 		// start
 		err = g.Run(ctx)
+
+		if n := g.Dropped(); n > 0 {
+			log.Warnf("Dropped %s events, as output did not keep up", humanize.Comma(n))
+		}
+
 		if err != nil {
 			return fmt.Errorf("crawling: %w", err)
 		}
 
-		close(events)
-		<-done
 		return nil
 	})
 }
 
 // serveCmd command.
-func serveCmd(app *kingpin.Application) {
+func serveCmd(app *kingpin.Application, config, profile *string) {
 	cmd := app.Command("serve", "Serve the generated website")
 	addr := cmd.Flag("address", "Bind address").Default("localhost:3000").String()
 	cmd.Action(func(_ *kingpin.ParseContext) error {
 		var c staticgen.Config
 
-		err := c.Load("static.json")
+		err := c.LoadProfile(*config, *profile)
 		if err != nil {
 			return fmt.Errorf("loading configuration: %w", err)
 		}
@@ -125,6 +193,23 @@ func serveCmd(app *kingpin.Application) {
 	})
 }
 
+// configCmd command.
+func configCmd(app *kingpin.Application, config, profile *string) {
+	cmd := app.Command("config", "Validate and output the effective configuration")
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		var c staticgen.Config
+
+		err := c.LoadProfile(*config, *profile)
+		if err != nil {
+			return fmt.Errorf("loading configuration: %w", err)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	})
+}
+
 // versionCmd command.
 func versionCmd(app *kingpin.Application) {
 	cmd := app.Command("version", "Output the version.").Hidden()
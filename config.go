@@ -1,20 +1,90 @@
 package staticgen
 
 import (
-	"github.com/tj/go-config"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/tj/staticgen/internal/jsonkeys"
+	"github.com/tj/staticgen/internal/overrides"
 )
 
 // Config is the static website generator configuration.
 type Config struct {
 	// URL is the target website to crawl. Defaults to "http://127.0.0.1:3000".
+	// A URL in the form "unix:///path/to.sock" is equivalent to setting
+	// Socket to the path, with a logical URL of "http://localhost".
 	URL string `json:"url"`
 
+	// Socket is an optional Unix domain socket path which is dialed for
+	// all requests, while the URL host is used for resolving links.
+	Socket string `json:"socket"`
+
 	// Dir is the static website output directory. Defaults to "build".
 	Dir string `json:"dir"`
 
 	// Command is the optional server command executed before crawling.
 	Command string `json:"command"`
 
+	// Env is an optional map of environment variables for the command,
+	// which may reference variables of the environment or EnvFile using
+	// ${VAR}, but not other entries of the map.
+	Env map[string]string `json:"env"`
+
+	// EnvFile is an optional ".env" file of environment variables for the command.
+	EnvFile string `json:"env_file"`
+
+	// Cwd is the optional working directory of the command.
+	Cwd string `json:"cwd"`
+
+	// Before is an optional hook command executed before the server
+	// is started, a failure aborts the build.
+	Before string `json:"before"`
+
+	// After is an optional hook command executed after the build, regardless
+	// of its success, including when it was cancelled or timed out, with the
+	// STATICGEN_STATUS environment variable set to "success" or "failure".
+	After string `json:"after"`
+
+	// FreePort can be enabled to pick an unused port for the server,
+	// which is substituted into the URL and passed to the command
+	// via the PORT environment variable.
+	FreePort bool `json:"free_port"`
+
+	// Log is an optional file which the server command output is appended to.
+	Log string `json:"log"`
+
+	// Ready is the readiness probe used to determine when
+	// the server is ready to accept requests.
+	Ready Ready `json:"ready"`
+
+	// StopSignal is the signal sent to the server process group
+	// to stop the server. Defaults to "SIGTERM".
+	StopSignal string `json:"stop_signal"`
+
+	// StopTimeout is the grace period after stopping the server, after
+	// which SIGKILL is sent if it has not exited. Defaults to "10s".
+	StopTimeout Duration `json:"stop_timeout"`
+
+	// StopCommand is an optional command executed to stop the
+	// server, instead of sending the StopSignal.
+	StopCommand string `json:"stop_command"`
+
+	// Services is an optional list of additional server processes the
+	// server command depends on, such as an API, started in dependency
+	// order before the server command, and stopped in reverse order.
+	Services []Service `json:"services"`
+
 	// Pages is a list of paths added to crawl, typically
 	// including unlinked pages such as error pages,
 	// landing pages and so on.
@@ -26,10 +96,237 @@ type Config struct {
 	// Allow404 can be enabled to opt-in to pages resulting in a 404,
 	// which otherwise lead to an error.
 	Allow404 bool `json:"allow_404"`
+
+	// Status is an optional map of path patterns to policies for accepted
+	// response status codes, for example {"/legacy/*": {"allow": [404]}}.
+	// Patterns may contain "*" to match any sequence of characters,
+	// and the longest matching pattern takes precedence.
+	Status map[string]StatusPolicy `json:"status"`
+
+	// Exclude is an optional list of path patterns which are not followed
+	// when discovered while crawling, for example ["/drafts/*"].
+	Exclude []string `json:"exclude"`
+
+	// Copy is an optional map of source files, directories or glob patterns
+	// to directories relative to Dir, which they are copied to after
+	// crawling, for example {"public/robots.txt": "/", "public/.well-known": "/"}.
+	// Copying a file which was also crawled, or two files to the same
+	// destination, is an error, and nothing is copied.
+	Copy map[string]string `json:"copy"`
+
+	// ErrorPages is an optional map of files, relative to Dir, to the paths
+	// fetched for their content regardless of the response status code,
+	// for example {"404.html": "/404"}. An empty path fetches a URL
+	// which is known to be missing, useful for custom 404 pages.
+	ErrorPages map[string]string `json:"error_pages"`
+
+	// Graph is an optional list of files, relative to Dir, which the link
+	// graph discovered while crawling is written to. The format is
+	// determined by the extension: ".json", ".dot" or ".csv".
+	Graph []string `json:"graph"`
+
+	// Manifest is the file, relative to Dir, which a JSON manifest of every
+	// file written is saved to, including its source URL, size, SHA-256
+	// checksum, status code and response headers of interest. Defaults
+	// to "staticgen-manifest.json", set to "" to disable it.
+	Manifest string `json:"manifest"`
+
+	// Stats is an optional file, relative to Dir, which a JSON summary of
+	// the build is saved to, including the status code distribution, bytes
+	// by content type, latency percentiles, and the slowest and largest
+	// resources, useful for tracking trends.
+	Stats string `json:"stats"`
+
+	// Headers is the optional configuration for exporting
+	// response headers for use by static website hosts.
+	Headers Headers `json:"headers"`
+
+	// Sites is an optional list of websites generated in turn using the
+	// same server, instead of the URL alone. Options not set by a site
+	// are inherited from the rest of the configuration.
+	Sites []Site `json:"sites"`
+}
+
+// Site is a website generated using the configured server.
+type Site struct {
+	// Name is the unique name of the site.
+	Name string `json:"name"`
+
+	// URL is the site URL, resolved relative to the configured URL,
+	// so a path such as "/docs/" may be used. Defaults to the configured URL.
+	URL string `json:"url"`
+
+	// Dir is the output directory of the site. Defaults to the
+	// configured Dir joined with the Name.
+	Dir string `json:"dir"`
+
+	// Pages is an optional list of additional paths to crawl.
+	Pages []string `json:"pages"`
+
+	// Status is an optional map of path patterns to status policies.
+	Status map[string]StatusPolicy `json:"status"`
+
+	// Exclude is an optional list of path patterns which are not followed.
+	Exclude []string `json:"exclude"`
+}
+
+// Service is a server process started before crawling.
+type Service struct {
+	// Name is the unique name of the service.
+	Name string `json:"name"`
+
+	// Command is the service command.
+	Command string `json:"command"`
+
+	// Env is an optional map of environment variables for the command,
+	// which may reference variables of the environment or EnvFile using
+	// ${VAR}, but not other entries of the map.
+	Env map[string]string `json:"env"`
+
+	// EnvFile is an optional ".env" file of environment variables for the command.
+	EnvFile string `json:"env_file"`
+
+	// Cwd is the optional working directory of the command.
+	Cwd string `json:"cwd"`
+
+	// URL is the optional URL the service listens on, used for the readiness
+	// probe. The service is not probed when empty. A URL in the form
+	// "unix:///path/to.sock" is equivalent to setting Socket to the path.
+	URL string `json:"url"`
+
+	// Socket is an optional Unix domain socket path the service listens on,
+	// which is dialed by the readiness probe.
+	Socket string `json:"socket"`
+
+	// Ready is the readiness probe used to determine when
+	// the service is ready to accept requests.
+	Ready Ready `json:"ready"`
+
+	// DependsOn is a list of service names which are started before this service.
+	DependsOn []string `json:"depends_on"`
+
+	// Log is an optional file which the command output is appended to.
+	Log string `json:"log"`
+
+	// StopSignal is the signal sent to the process group
+	// to stop the service. Defaults to "SIGTERM".
+	StopSignal string `json:"stop_signal"`
+
+	// StopTimeout is the grace period after stopping the service, after
+	// which SIGKILL is sent if it has not exited. Defaults to "10s".
+	StopTimeout Duration `json:"stop_timeout"`
+
+	// StopCommand is an optional command executed to stop the
+	// service, instead of sending the StopSignal.
+	StopCommand string `json:"stop_command"`
+}
+
+// Ready is the server readiness probe configuration.
+type Ready struct {
+	// Path is the request path, resolved relative to the URL like a link,
+	// so "/health" replaces its path, while "health" is appended to a URL
+	// path ending in a slash. Defaults to the URL itself.
+	Path string `json:"path"`
+
+	// Method is the request method. Defaults to "HEAD", or "GET" when Body is set.
+	Method string `json:"method"`
+
+	// Status is the expected response status code. Defaults to any status.
+	Status int `json:"status"`
+
+	// Body is an optional substring expected in the response body.
+	Body string `json:"body"`
+
+	// TCP can be enabled to only check that the server accepts connections.
+	TCP bool `json:"tcp"`
+
+	// Interval is the time between checks. Defaults to "1s".
+	Interval Duration `json:"interval"`
+
+	// Timeout is the maximum time to wait for the server. Defaults to "15s".
+	Timeout Duration `json:"timeout"`
+}
+
+// StatusPolicy is a policy for accepted response status codes.
+type StatusPolicy struct {
+	// Allow is a list of non-2xx status codes which do not lead to an error.
+	Allow []int `json:"allow"`
+
+	// Save can be enabled to save the response body of allowed status codes.
+	Save bool `json:"save"`
+}
+
+// Headers is the response header export configuration.
+type Headers struct {
+	// Allow is the list of response header names to keep, such as
+	// "Cache-Control" or "Content-Security-Policy".
+	Allow []string `json:"allow"`
+
+	// Format is the export format, one of "netlify" for a Netlify or
+	// Cloudflare Pages "_headers" file, "nginx" for an nginx include file,
+	// or "s3" for S3 object metadata JSON. Defaults to "netlify".
+	Format string `json:"format"`
+
+	// File is the output file relative to Dir. Defaults to "_headers",
+	// "headers.conf" or "headers.json" depending on the Format.
+	File string `json:"file"`
+}
+
+// configFiles is a list of configuration files searched for, in order.
+var configFiles = []string{
+	"static.json",
+	"static.yaml",
+	"static.yml",
+	"static.toml",
 }
 
 // Load configuration from the given path.
 func (c *Config) Load(path string) error {
+	return c.LoadProfile(path, "")
+}
+
+// LoadProfile loads configuration from the given path, in JSON, YAML or TOML
+// format depending on its extension, searching for ./static.json,
+// ./static.yaml, ./static.yml or ./static.toml when the path is empty.
+// No error is returned if none of those exist, however an explicit path
+// must exist. When a profile is given
+// the settings of the named entry in the file's "profiles" object are
+// applied on top of the others. STATICGEN_* environment variables are
+// applied last, see LoadWith.
+func (c *Config) LoadProfile(path, profile string) error {
+	return c.LoadWith(LoadOptions{
+		Path:    path,
+		Profile: profile,
+		Environ: os.Environ(),
+	})
+}
+
+// LoadOptions are the sources of configuration for LoadWith.
+type LoadOptions struct {
+	// Path is the configuration file path, see LoadProfile.
+	Path string
+
+	// Profile is the optional profile name, see LoadProfile.
+	Profile string
+
+	// Environ is an optional list of "KEY=value" environment variables,
+	// where STATICGEN_* variables override the config field of the
+	// same name, such as STATICGEN_READY_TIMEOUT for "ready.timeout".
+	// Maps, lists of objects, and the url, dir and socket fields, which
+	// staticgen sets for the commands it runs, are not overridden.
+	Environ []string
+
+	// Overrides is an optional map of config keys, such as "url" or
+	// "ready.timeout", to values, typically from command-line flags.
+	Overrides map[string]string
+}
+
+// LoadWith loads configuration from each source in order of precedence,
+// from lowest to highest: defaults, the configuration file, the profile,
+// environment variables, and finally overrides. Override values are
+// strings parsed according to the field's type, with lists
+// comma-separated, and maps or lists of objects as JSON.
+func (c *Config) LoadWith(o LoadOptions) error {
 	if c.URL == "" {
 		c.URL = "http://127.0.0.1:3000"
 	}
@@ -42,10 +339,370 @@ func (c *Config) Load(path string) error {
 		c.Concurrency = 30
 	}
 
-	err := config.Load(path, c)
+	if c.Manifest == "" {
+		c.Manifest = "staticgen-manifest.json"
+	}
+
+	path := o.Path
+	optional := path == ""
+	if optional {
+		path = findConfig()
+	}
+
+	problems, err := c.load(path, o.Profile, optional)
+	if err != nil {
+		return err
+	}
+
+	problems = append(problems, c.override(o.Environ, o.Overrides)...)
+
+	c.Ready.defaults()
+	stopDefaults(&c.StopSignal, &c.StopTimeout)
+
+	socket, u, err := parseSocketURL(c.URL)
+	if err != nil {
+		return fmt.Errorf("parsing url: %w", err)
+	}
+
+	if socket != "" {
+		c.Socket = socket
+		c.URL = u
+	}
+
+	for i := range c.Services {
+		s := &c.Services[i]
+		s.Ready.defaults()
+		stopDefaults(&s.StopSignal, &s.StopTimeout)
+
+		socket, u, err := parseSocketURL(s.URL)
+		if err != nil {
+			return fmt.Errorf("parsing service %q url: %w", s.Name, err)
+		}
+
+		if socket != "" {
+			s.Socket = socket
+			s.URL = u
+		}
+	}
+
+	for i := range c.Sites {
+		s := &c.Sites[i]
+		if s.Dir == "" {
+			s.Dir = filepath.Join(c.Dir, s.Name)
+		}
+	}
+
+	if c.Headers.Format == "" {
+		c.Headers.Format = "netlify"
+	}
+
+	if c.Headers.File == "" {
+		switch c.Headers.Format {
+		case "netlify":
+			c.Headers.File = "_headers"
+		case "nginx":
+			c.Headers.File = "headers.conf"
+		case "s3":
+			c.Headers.File = "headers.json"
+		}
+	}
+
+	problems = append(problems, c.problems()...)
+	if len(problems) > 0 {
+		return &ValidationError{
+			Problems: problems,
+		}
+	}
+
+	return nil
+}
+
+// exportedKeys are the config keys whose STATICGEN_* variables staticgen
+// sets for the commands it runs, which are not read from the environment
+// so that they are not inherited by a nested build.
+var exportedKeys = map[string]bool{
+	"dir":    true,
+	"socket": true,
+	"url":    true,
+}
+
+// override applies STATICGEN_* environment variables followed by the
+// overrides, returning invalid values as problems. Only options with
+// plain values are read from the environment, so unrelated variables
+// such as STATICGEN_ENV are ignored, see exportedKeys.
+func (c *Config) override(environ []string, values map[string]string) (problems []string) {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i != -1 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	for _, key := range overrides.Keys(c) {
+		if exportedKeys[key] || !overrides.Scalar(c, key) {
+			continue
+		}
+
+		name := envName(key)
+		if v, ok := env[name]; ok {
+			if err := overrides.Set(c, key, v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+			}
+		}
+	}
+
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := overrides.Set(c, key, values[key]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", key, err))
+		}
+	}
+
+	return
+}
+
+// envName returns the environment variable name for a config key.
+func envName(key string) string {
+	return "STATICGEN_" + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+// configFile is the structure of a configuration file.
+type configFile struct {
+	Config
+	Profiles map[string]Config `json:"profiles"`
+}
+
+// load configuration from path, applying the profile, and returning
+// any unknown keys or invalid values as problems. A missing file is
+// ignored when optional.
+func (c *Config) load(path, profile string, optional bool) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) && optional && profile == "" {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(path)
+	b, err = toJSON(b, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	// locate returns msg prefixed with the path, and position for JSON
+	locate := func(offset int64, msg string) string {
+		if offset >= 0 && (ext == ".json" || ext == "") {
+			line, col := jsonkeys.Position(b, offset)
+			return fmt.Sprintf("%s:%d:%d: %s", path, line, col, msg)
+		}
+		return fmt.Sprintf("%s: %s", path, msg)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if err := json.Unmarshal(b, new(interface{})); errors.As(err, &syntaxErr) {
+		return []string{locate(syntaxErr.Offset-1, syntaxErr.Error())}, nil
+	}
+
+	keys, err := jsonkeys.Unknown(b, &configFile{})
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, k := range keys {
+		msg := fmt.Sprintf("%s: unknown key %q", path, k.Path)
+
+		if ext == ".json" || ext == "" {
+			msg = fmt.Sprintf("%s:%d:%d: unknown key %q", path, k.Line, k.Column, k.Path)
+		}
+
+		if k.Suggestion != "" {
+			msg += fmt.Sprintf(", did you mean %q?", k.Suggestion)
+		}
+
+		problems = append(problems, msg)
+	}
+
+	// config
+	f := struct {
+		*Config
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}{
+		Config: c,
+	}
+
+	err = json.Unmarshal(b, &f)
+	if err != nil {
+		problems = append(problems, decodeProblem(err, 0, locate))
+	}
+
+	if profile == "" {
+		return problems, nil
+	}
+
+	// profile
+	p, ok := f.Profiles[profile]
+	if !ok {
+		return problems, fmt.Errorf("profile %q is not defined", profile)
+	}
+
+	err = json.Unmarshal(p, c)
+	if err != nil {
+		offset := int64(bytes.Index(b, p))
+		problems = append(problems, decodeProblem(err, offset, locate))
+	}
+
+	return problems, nil
+}
+
+// decodeProblem returns a problem for the JSON decoding error err, located
+// relative to the offset of the decoded value when it is a type error, and
+// the offset is known.
+func decodeProblem(err error, offset int64, locate func(int64, string) string) string {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return locate(-1, err.Error())
+	}
+
+	msg := fmt.Sprintf("%s: cannot use %s as %s", typeErr.Field, typeErr.Value, typeErr.Type)
+	if offset < 0 {
+		return locate(-1, msg)
+	}
+
+	return locate(offset+typeErr.Offset, msg)
+}
+
+// findConfig returns the first configuration file which
+// exists, defaulting to the first if none exist.
+func findConfig() string {
+	for _, path := range configFiles {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return configFiles[0]
+}
+
+// toJSON returns configuration in the format for the given extension as JSON.
+func toJSON(b []byte, ext string) ([]byte, error) {
+	var v interface{}
+
+	switch ext {
+	case ".json", "":
+		return b, nil
+	case ".yaml", ".yml":
+		err := yaml.Unmarshal(b, &v)
+		if err != nil {
+			return nil, err
+		}
+		v, err = normalizeYAML(v)
+		if err != nil {
+			return nil, err
+		}
+	case ".toml":
+		var m map[string]interface{}
+		_, err := toml.Decode(string(b), &m)
+		if err != nil {
+			return nil, err
+		}
+		v = m
+	default:
+		return nil, fmt.Errorf("unsupported configuration format %q", ext)
+	}
+
+	return json.Marshal(v)
+}
+
+// normalizeYAML returns v with YAML maps converted to string keyed maps.
+func normalizeYAML(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range v {
+			s, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("non-string key %v", k)
+			}
+
+			n, err := normalizeYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			m[s] = n
+		}
+		return m, nil
+	case []interface{}:
+		for i := range v {
+			n, err := normalizeYAML(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = n
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// defaults applies the readiness probe defaults.
+func (r *Ready) defaults() {
+	if r.Method == "" {
+		r.Method = "HEAD"
+		if r.Body != "" {
+			r.Method = "GET"
+		}
+	}
+
+	if r.Interval == 0 {
+		r.Interval = Duration(time.Second)
+	}
+
+	if r.Timeout == 0 {
+		r.Timeout = Duration(15 * time.Second)
+	}
+}
+
+// stopDefaults applies the stop signal and timeout defaults.
+func stopDefaults(signal *string, timeout *Duration) {
+	if *signal == "" {
+		*signal = "SIGTERM"
+	}
+
+	if *timeout == 0 {
+		*timeout = Duration(10 * time.Second)
+	}
+}
+
+// Duration is a time.Duration represented in JSON as a string such as "15s".
+type Duration time.Duration
+
+// UnmarshalJSON implementation.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return fmt.Errorf("duration must be a string such as \"15s\"")
+	}
+
+	v, err := time.ParseDuration(s)
 	if err != nil {
 		return err
 	}
 
+	*d = Duration(v)
 	return nil
 }
+
+// MarshalJSON implementation.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
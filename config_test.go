@@ -0,0 +1,248 @@
+package staticgen_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen"
+)
+
+// writeConfig writes a configuration file to a temporary directory.
+func writeConfig(t testing.TB, name, s string) string {
+	dir, err := ioutil.TempDir("", "staticgen")
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(s), 0644)
+	assert.NoError(t, err)
+
+	return path
+}
+
+// Test loading each configuration format.
+func TestConfig_LoadWith_formats(t *testing.T) {
+	files := map[string]string{
+		"static.json": `{
+  "url": "http://127.0.0.1:5000",
+  "concurrency": 5,
+  "ready": { "path": "/health", "timeout": "5s" },
+  "services": [{ "name": "api", "command": "./api", "env": { "PORT": "4000" } }]
+}`,
+		"static.yaml": `
+url: http://127.0.0.1:5000
+concurrency: 5
+ready:
+  path: /health
+  timeout: 5s
+services:
+  - name: api
+    command: ./api
+    env:
+      PORT: "4000"
+`,
+		"static.toml": `
+url = "http://127.0.0.1:5000"
+concurrency = 5
+
+[ready]
+path = "/health"
+timeout = "5s"
+
+[[services]]
+name = "api"
+command = "./api"
+
+[services.env]
+PORT = "4000"
+`,
+	}
+
+	for name, s := range files {
+		t.Run(filepath.Ext(name), func(t *testing.T) {
+			path := writeConfig(t, name, s)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			var c staticgen.Config
+			err := c.LoadWith(staticgen.LoadOptions{Path: path})
+			assert.NoError(t, err)
+
+			assert.Equal(t, "http://127.0.0.1:5000", c.URL)
+			assert.Equal(t, 5, c.Concurrency)
+			assert.Equal(t, "/health", c.Ready.Path)
+			assert.Equal(t, staticgen.Duration(5*time.Second), c.Ready.Timeout)
+			assert.Len(t, c.Services, 1)
+			assert.Equal(t, "api", c.Services[0].Name)
+			assert.Equal(t, map[string]string{"PORT": "4000"}, c.Services[0].Env)
+		})
+	}
+}
+
+// Test loading YAML with non-string keys.
+func TestConfig_LoadWith_yamlKeys(t *testing.T) {
+	path := writeConfig(t, "static.yml", "env:\n  1: one\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var c staticgen.Config
+	err := c.LoadWith(staticgen.LoadOptions{Path: path})
+	assert.EqualError(t, err, `non-string key 1`)
+}
+
+// Test selecting a profile.
+func TestConfig_LoadWith_profile(t *testing.T) {
+	path := writeConfig(t, "static.json", `{
+  "url": "http://127.0.0.1:5000",
+  "concurrency": 5,
+  "profiles": {
+    "ci": { "concurrency": 2, "allow_404": true }
+  }
+}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	t.Run("none", func(t *testing.T) {
+		var c staticgen.Config
+		err := c.LoadWith(staticgen.LoadOptions{Path: path})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, c.Concurrency)
+		assert.False(t, c.Allow404)
+	})
+
+	t.Run("defined", func(t *testing.T) {
+		var c staticgen.Config
+		err := c.LoadWith(staticgen.LoadOptions{Path: path, Profile: "ci"})
+		assert.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:5000", c.URL)
+		assert.Equal(t, 2, c.Concurrency)
+		assert.True(t, c.Allow404)
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		var c staticgen.Config
+		err := c.LoadWith(staticgen.LoadOptions{Path: path, Profile: "staging"})
+		assert.EqualError(t, err, `profile "staging" is not defined`)
+	})
+}
+
+// Test loading a missing configuration file.
+func TestConfig_LoadWith_missing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "staticgen")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("explicit", func(t *testing.T) {
+		var c staticgen.Config
+		err := c.LoadWith(staticgen.LoadOptions{Path: filepath.Join(dir, "static.json")})
+		assert.True(t, os.IsNotExist(err), "not exist")
+	})
+
+	t.Run("default", func(t *testing.T) {
+		wd, err := os.Getwd()
+		assert.NoError(t, err)
+		assert.NoError(t, os.Chdir(dir))
+		defer os.Chdir(wd)
+
+		var c staticgen.Config
+		err = c.LoadWith(staticgen.LoadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:3000", c.URL)
+		assert.Equal(t, "build", c.Dir)
+		assert.Equal(t, "staticgen-manifest.json", c.Manifest)
+	})
+}
+
+// Test disabling the manifest.
+func TestConfig_LoadWith_manifest(t *testing.T) {
+	path := writeConfig(t, "static.json", `{ "manifest": "" }`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var c staticgen.Config
+	err := c.LoadWith(staticgen.LoadOptions{Path: path})
+	assert.NoError(t, err)
+	assert.Equal(t, "", c.Manifest)
+}
+
+// Test validating the configuration.
+func TestConfig_LoadWith_validation(t *testing.T) {
+	path := writeConfig(t, "static.json", `{ "concurrency": 0 }`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var c staticgen.Config
+	err := c.LoadWith(staticgen.LoadOptions{Path: path})
+	assert.EqualError(t, err, "invalid configuration:\n  - concurrency: must be at least 1, got 0")
+}
+
+// Test validating free_port.
+func TestConfig_LoadWith_freePort(t *testing.T) {
+	path := writeConfig(t, "static.json", `{ "free_port": true }`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var c staticgen.Config
+	err := c.LoadWith(staticgen.LoadOptions{Path: path})
+	assert.EqualError(t, err, "invalid configuration:\n  - free_port: requires a command")
+}
+
+// Test reporting all problems at once.
+func TestConfig_LoadWith_problems(t *testing.T) {
+	t.Run("invalid values", func(t *testing.T) {
+		path := writeConfig(t, "static.json", `{
+  "alow_404": true,
+  "concurrency": "5",
+  "url": "ftp://example.com",
+  "profiles": {
+    "ci": { "dir": 3 }
+  }
+}`)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		var c staticgen.Config
+		err := c.LoadWith(staticgen.LoadOptions{Path: path, Profile: "ci"})
+		assert.EqualError(t, err, fmt.Sprintf(`invalid configuration:
+  - %[1]s:2:3: unknown key "alow_404", did you mean "allow_404"?
+  - %[1]s:3:21: concurrency: cannot use string as int
+  - %[1]s:6:21: dir: cannot use number as string
+  - url: scheme must be http, https or unix, got "ftp"`, path))
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		path := writeConfig(t, "static.json", "{\n  \"url\": \"http://example.com\",\n}")
+		defer os.RemoveAll(filepath.Dir(path))
+
+		var c staticgen.Config
+		err := c.LoadWith(staticgen.LoadOptions{Path: path})
+		assert.EqualError(t, err, fmt.Sprintf("invalid configuration:\n  - %s:3:1: invalid character '}' looking for beginning of object key string", path))
+	})
+}
+
+// Test overriding options with environment variables.
+func TestConfig_LoadWith_environ(t *testing.T) {
+	path := writeConfig(t, "static.json", `{ "url": "http://127.0.0.1:5000" }`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var c staticgen.Config
+	err := c.LoadWith(staticgen.LoadOptions{
+		Path: path,
+		Environ: []string{
+			"STATICGEN_CONCURRENCY=5",
+			"STATICGEN_PAGES=/,/about",
+			"STATICGEN_READY_TIMEOUT=5s",
+			"STATICGEN_ENV=production",
+			"STATICGEN_STATUS=success",
+			"STATICGEN_URL=http://127.0.0.1:4000",
+			"STATICGEN_DIR=/tmp/parent",
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5, c.Concurrency)
+	assert.Equal(t, []string{"/", "/about"}, c.Pages)
+	assert.Equal(t, staticgen.Duration(5*time.Second), c.Ready.Timeout)
+	assert.Nil(t, c.Env)
+	assert.Nil(t, c.Status)
+	assert.Equal(t, "http://127.0.0.1:5000", c.URL)
+	assert.Equal(t, "build", c.Dir)
+}
@@ -0,0 +1,131 @@
+package staticgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// copyFiles copies the configured files into the output directory,
+// failing without copying any if one would overwrite a crawled file,
+// or another file copied to the same destination.
+func (g *Generator) copyFiles() error {
+	crawled := make(map[string]bool)
+	for _, r := range g.results {
+		if r.saved() {
+			crawled[filepath.Clean(r.Filename)] = true
+		}
+	}
+
+	var patterns []string
+	for pattern := range g.Copy {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	// destinations
+	var files []string
+	sources := make(map[string]string)
+	var conflicts []string
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return fmt.Errorf("%q does not match any files", pattern)
+		}
+
+		dir := filepath.Join(g.Dir, g.Copy[pattern])
+
+		for _, src := range matches {
+			base := filepath.Dir(src)
+			err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if info.IsDir() {
+					return nil
+				}
+
+				rel, err := filepath.Rel(base, path)
+				if err != nil {
+					return err
+				}
+
+				dst := filepath.Join(dir, rel)
+
+				if crawled[dst] {
+					conflicts = append(conflicts, fmt.Sprintf("%s conflicts with crawled file %s", path, dst))
+					return nil
+				}
+
+				if prev, ok := sources[dst]; ok {
+					if prev != path {
+						conflicts = append(conflicts, fmt.Sprintf("%s conflicts with %s copied to %s", path, prev, dst))
+					}
+					return nil
+				}
+
+				sources[dst] = path
+				files = append(files, dst)
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicts:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+
+	// copy
+	for _, dst := range files {
+		err := g.copyFile(sources[dst], dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the file src to dst.
+func (g *Generator) copyFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := writeFile(io.TeeReader(f, h), dst)
+	if err != nil {
+		return err
+	}
+
+	g.record(result{
+		Source:   src,
+		Filename: dst,
+		Size:     n,
+		SHA256:   hex.EncodeToString(h.Sum(nil)),
+	})
+
+	g.emit(EventCopiedFile{
+		Source:   src,
+		Filename: dst,
+	})
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package staticgen_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// writeFiles writes files relative to dir.
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	for name, s := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(s), 0644))
+	}
+}
+
+// Test copying files.
+func TestGenerator_Run_copy(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `Home`)
+	})
+
+	t.Run("copied", func(t *testing.T) {
+		g, dir := generator(t, mux, nil)
+		root := filepath.Dir(dir)
+		defer os.RemoveAll(root)
+
+		writeFiles(t, root, map[string]string{
+			"public/robots.txt":               "User-agent: *",
+			"public/.well-known/security.txt": "Contact: security@example.com",
+		})
+
+		g.Copy = map[string]string{
+			filepath.Join(root, "public/robots.txt"):  "/",
+			filepath.Join(root, "public/.well-known"): "/",
+		}
+
+		err := g.Run(context.Background())
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "robots.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "User-agent: *", string(b))
+
+		b, err = ioutil.ReadFile(filepath.Join(dir, ".well-known/security.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Contact: security@example.com", string(b))
+	})
+
+	t.Run("crawled file conflict", func(t *testing.T) {
+		g, dir := generator(t, mux, nil)
+		root := filepath.Dir(dir)
+		defer os.RemoveAll(root)
+
+		writeFiles(t, root, map[string]string{
+			"public/robots.txt": "User-agent: *",
+			"public/index.html": "Copied",
+		})
+
+		g.Copy = map[string]string{
+			filepath.Join(root, "public/*"): "/",
+		}
+
+		err := g.Run(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "conflicts with crawled file "+filepath.Join(dir, "index.html"))
+
+		_, err = os.Stat(filepath.Join(dir, "robots.txt"))
+		assert.True(t, os.IsNotExist(err), "robots.txt should not be copied")
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Home", string(b))
+	})
+
+	t.Run("duplicate destination", func(t *testing.T) {
+		g, dir := generator(t, mux, nil)
+		root := filepath.Dir(dir)
+		defer os.RemoveAll(root)
+
+		writeFiles(t, root, map[string]string{
+			"a/robots.txt": "User-agent: a",
+			"b/robots.txt": "User-agent: b",
+			"c/humans.txt": "Humans",
+		})
+
+		g.Copy = map[string]string{
+			filepath.Join(root, "a/robots.txt"): "/",
+			filepath.Join(root, "b/robots.txt"): "/",
+			filepath.Join(root, "c/humans.txt"): "/",
+		}
+
+		err := g.Run(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("%s conflicts with %s copied to %s",
+			filepath.Join(root, "b/robots.txt"),
+			filepath.Join(root, "a/robots.txt"),
+			filepath.Join(dir, "robots.txt")))
+
+		_, err = os.Stat(filepath.Join(dir, "robots.txt"))
+		assert.True(t, os.IsNotExist(err), "robots.txt should not be copied")
+
+		_, err = os.Stat(filepath.Join(dir, "humans.txt"))
+		assert.True(t, os.IsNotExist(err), "humans.txt should not be copied")
+	})
+}
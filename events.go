@@ -1,6 +1,8 @@
 package staticgen
 
 import (
+	"encoding/json"
+	"net/url"
 	"time"
 )
 
@@ -11,38 +13,132 @@ type Event interface {
 
 // EventStartingServer .
 type EventStartingServer struct {
-	Command string
-	URL     string
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	URL     string `json:"url"`
 }
 
 // EventStartedServer .
 type EventStartedServer struct {
-	Command string
-	URL     string
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	URL     string `json:"url"`
+}
+
+// EventRunningHook .
+type EventRunningHook struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// EventOutput is a line of output from a command.
+type EventOutput struct {
+	Name string `json:"name"`
+	Line string `json:"line"`
 }
 
 // EventStoppingServer .
-type EventStoppingServer struct{}
+type EventStoppingServer struct {
+	Name    string `json:"name"`
+	Signal  string `json:"signal"`
+	Command string `json:"command"`
+}
 
-// EventStartCrawl .
-type EventStartCrawl struct{}
+// EventStoppedServer is emitted when the server has exited, with its
+// exit code, or the signal which terminated it. Killed is true when
+// SIGKILL was sent after the stop timeout was exceeded.
+type EventStoppedServer struct {
+	Name     string `json:"name"`
+	ExitCode int    `json:"exit_code"`
+	Signal   string `json:"signal"`
+	Killed   bool   `json:"killed"`
+}
+
+// EventStartCrawl is emitted when crawling of the URL begins, with
+// the Site name when multiple sites are configured.
+type EventStartCrawl struct {
+	Site string `json:"site"`
+	URL  string `json:"url"`
+}
 
-// EventStopCrawl .
-type EventStopCrawl struct{}
+// EventStopCrawl is emitted when the site has been generated,
+// with statistics of the resources visited.
+type EventStopCrawl struct {
+	Site  string `json:"site"`
+	Stats Stats  `json:"stats"`
+}
 
-// EventVisitedResource .
+// EventVisitedResource is emitted for each resource requested, with
+// the Parent URL it was discovered on, its ContentType and Size in bytes,
+// the number of resources Queued for crawling at the time, and the
+// chain of Redirects followed, if any.
 type EventVisitedResource struct {
 	Target
-	Duration   time.Duration
-	StatusCode int
-	Filename   string
-	Error      error
+	Duration    time.Duration
+	StatusCode  int
+	ContentType string
+	Size        int64
+	Filename    string
+	Error       error
+	Queued      int
+	Redirects   []*url.URL
+}
+
+// MarshalJSON implementation.
+func (e EventVisitedResource) MarshalJSON() ([]byte, error) {
+	v := struct {
+		URL         string   `json:"url"`
+		Parent      string   `json:"parent,omitempty"`
+		Duration    float64  `json:"duration_ms"`
+		StatusCode  int      `json:"status_code"`
+		ContentType string   `json:"content_type,omitempty"`
+		Size        int64    `json:"size"`
+		Filename    string   `json:"filename,omitempty"`
+		Error       string   `json:"error,omitempty"`
+		Queued      int      `json:"queued"`
+		Redirects   []string `json:"redirects,omitempty"`
+	}{
+		Duration:    float64(e.Duration) / float64(time.Millisecond),
+		StatusCode:  e.StatusCode,
+		ContentType: e.ContentType,
+		Size:        e.Size,
+		Filename:    e.Filename,
+		Queued:      e.Queued,
+	}
+
+	if e.URL != nil {
+		v.URL = e.URL.String()
+	}
+
+	if e.Parent != nil {
+		v.Parent = e.Parent.String()
+	}
+
+	if e.Error != nil {
+		v.Error = e.Error.Error()
+	}
+
+	for _, u := range e.Redirects {
+		v.Redirects = append(v.Redirects, u.String())
+	}
+
+	return json.Marshal(v)
+}
+
+// EventCopiedFile .
+type EventCopiedFile struct {
+	Source   string `json:"source"`
+	Filename string `json:"filename"`
 }
 
 // event implementation.
 func (e EventStartingServer) event()  {}
 func (e EventStartedServer) event()   {}
+func (e EventRunningHook) event()     {}
+func (e EventOutput) event()          {}
 func (e EventStoppingServer) event()  {}
+func (e EventStoppedServer) event()   {}
 func (e EventStartCrawl) event()      {}
 func (e EventStopCrawl) event()       {}
 func (e EventVisitedResource) event() {}
+func (e EventCopiedFile) event()      {}
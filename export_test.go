@@ -0,0 +1,18 @@
+package staticgen
+
+// SortServices is exported for testing.
+var SortServices = sortServices
+
+// ParseSignal is exported for testing.
+var ParseSignal = parseSignal
+
+// ReadyURL is exported for testing.
+var ReadyURL = readyURL
+
+// MarshalEvent is exported for testing.
+var MarshalEvent = marshalEvent
+
+// EventEmpty is an event without fields, for testing.
+type EventEmpty struct{}
+
+func (EventEmpty) event() {}
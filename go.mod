@@ -3,6 +3,7 @@ module github.com/tj/staticgen
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/PuerkitoBio/goquery v1.5.0
 	github.com/alecthomas/assert v0.0.0-20170929043011-405dbfeb8e38 // indirect
 	github.com/alecthomas/colour v0.0.0-20160524082231-60882d9e2721 // indirect
@@ -14,7 +15,7 @@ require (
 	github.com/dustin/go-humanize v1.0.0
 	github.com/pkg/browser v0.0.0-20180916011732-0a3d74bf9ce4
 	github.com/tj/assert v0.0.0-20190920132354-ee03d75cd160
-	github.com/tj/go-config v1.3.0
 	github.com/tj/kingpin v2.5.0+incompatible
 	golang.org/x/net v0.0.0-20190827160401-ba9fcec4b297 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,66 @@
+package staticgen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// runBeforeHook runs the before hook command, if any.
+func (g *Generator) runBeforeHook(ctx context.Context) error {
+	if g.Before == "" {
+		return nil
+	}
+
+	return g.runHook(ctx, "before", g.Before, nil)
+}
+
+// afterHookTimeout is the maximum duration of the after hook, which
+// does not use the build context so that it runs after a cancellation.
+const afterHookTimeout = 5 * time.Minute
+
+// runAfterHook runs the after hook command, if any, with
+// the status of the build given its error.
+func (g *Generator) runAfterHook(err error) error {
+	if g.After == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), afterHookTimeout)
+	defer cancel()
+
+	env := []string{"STATICGEN_STATUS=success"}
+	if err != nil {
+		env = []string{"STATICGEN_STATUS=failure", "STATICGEN_ERROR=" + err.Error()}
+	}
+
+	return g.runHook(ctx, "after", g.After, env)
+}
+
+// runHook runs a hook command to completion, streaming its output as events.
+func (g *Generator) runHook(ctx context.Context, name, command string, env []string) error {
+	g.emit(EventRunningHook{
+		Name:    name,
+		Command: command,
+	})
+
+	out := &outputWriter{
+		name: name,
+		emit: g.emit,
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), "STATICGEN=1", "STATICGEN_DIR="+g.Dir, "STATICGEN_URL="+g.URL)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	out.Flush()
+	if err != nil {
+		return fmt.Errorf("%w%s", err, out.Tail())
+	}
+
+	return nil
+}
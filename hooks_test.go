@@ -0,0 +1,78 @@
+package staticgen_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// Test running hooks.
+func TestGenerator_Run_hooks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `Home`)
+	})
+
+	t.Run("after success", func(t *testing.T) {
+		g, dir := generator(t, mux, nil)
+		root := filepath.Dir(dir)
+		defer os.RemoveAll(root)
+
+		out := filepath.Join(root, "after.txt")
+		g.After = fmt.Sprintf(`echo "$STATICGEN_STATUS $STATICGEN_DIR" > %q`, out)
+
+		err := g.Run(context.Background())
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "success "+dir+"\n", string(b))
+	})
+
+	t.Run("after cancellation", func(t *testing.T) {
+		g, dir := generator(t, mux, nil)
+		root := filepath.Dir(dir)
+		defer os.RemoveAll(root)
+
+		out := filepath.Join(root, "after.txt")
+		g.After = fmt.Sprintf(`echo "$STATICGEN_STATUS" > %q`, out)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := g.Run(ctx)
+		assert.Error(t, err)
+
+		b, err := ioutil.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "failure\n", string(b))
+	})
+
+	t.Run("before failure", func(t *testing.T) {
+		g, dir := generator(t, mux, nil)
+		root := filepath.Dir(dir)
+		defer os.RemoveAll(root)
+
+		out := filepath.Join(root, "after.txt")
+		g.Before = "echo compiling; exit 1"
+		g.After = fmt.Sprintf(`echo "$STATICGEN_STATUS" > %q`, out)
+
+		err := g.Run(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "running before hook: exit status 1")
+		assert.Contains(t, err.Error(), "before output:\n  compiling")
+
+		_, err = os.Stat(filepath.Join(dir, "index.html"))
+		assert.True(t, os.IsNotExist(err), "index.html should not be crawled")
+
+		b, err := ioutil.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "failure\n", string(b))
+	})
+}
@@ -0,0 +1,81 @@
+package staticgen
+
+import (
+	"io"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/tj/staticgen/internal/report"
+)
+
+// An HTMLReporter outputs a self-contained HTML report when closed, with
+// sortable tables of resources, errors with their referrers, redirect
+// chains, the largest assets, and a histogram of response times.
+type HTMLReporter struct {
+	// Writer is the output destination.
+	Writer io.Writer
+
+	site      string
+	start     time.Time
+	resources []report.Resource
+}
+
+// Report on the given event channel. Returns a channel which is
+// closed when the event channel is closed, and all reporting has
+// been completed.
+func (r *HTMLReporter) Report(ch <-chan Event) <-chan struct{} {
+	return forward(ch, r)
+}
+
+// Handle implementation.
+func (r *HTMLReporter) Handle(e Event) {
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+
+	switch e := e.(type) {
+	case EventStartCrawl:
+		r.site = e.Site
+	case EventVisitedResource:
+		res := report.Resource{
+			Site:        r.site,
+			StatusCode:  e.StatusCode,
+			ContentType: e.ContentType,
+			Size:        e.Size,
+			Duration:    e.Duration,
+		}
+
+		if e.URL != nil {
+			res.URL = e.URL.String()
+		}
+
+		if e.Parent != nil {
+			res.Parent = e.Parent.String()
+		}
+
+		if e.Error != nil {
+			res.Error = e.Error.Error()
+		}
+
+		for _, u := range e.Redirects {
+			res.Redirects = append(res.Redirects, u.String())
+		}
+
+		r.resources = append(r.resources, res)
+	}
+}
+
+// Close implementation.
+func (r *HTMLReporter) Close() {
+	err := report.Write(r.Writer, report.Report{
+		Title:     "Staticgen report",
+		Generated: time.Now(),
+		Duration:  time.Since(r.start).Round(time.Millisecond),
+		Resources: r.resources,
+	})
+
+	if err != nil {
+		log.WithError(err).Error("writing html report")
+	}
+}
@@ -4,6 +4,7 @@ package crawler
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,10 +14,12 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	dom "github.com/PuerkitoBio/goquery"
 	"github.com/tj/staticgen/internal/deduplicator"
+	"github.com/tj/staticgen/internal/glob"
 )
 
 // atImportRe is the regexp used for parsing @import directives.
@@ -33,24 +36,62 @@ type Target struct {
 type Resource struct {
 	Target
 	StatusCode int
+	Header     http.Header
 	Duration   time.Duration
 	Body       io.ReadCloser
 	Error      error
+
+	// Discard is true when the response status was accepted
+	// by a StatusPolicy, but the body should not be saved.
+	Discard bool
+
+	// Redirects is the chain of URLs redirected to, if any,
+	// ending with the URL of the response.
+	Redirects []*url.URL
+
+	// Links is the list of URLs followed from the page, including
+	// those which were already visited or queued by another page.
+	Links []*url.URL
+}
+
+// A StatusPolicy determines the non-2xx response status codes accepted
+// for paths matching Pattern, and whether the response body is saved.
+type StatusPolicy struct {
+	Pattern string
+	Allow   []int
+	Save    bool
+}
+
+// policy is a compiled status policy.
+type policy struct {
+	StatusPolicy
+	pattern *glob.Pattern
 }
 
 // A Crawler is in charge of visiting or "crawling"
-// all pages and assets of a particular URL.
+// all pages and assets of a particular URL. When Handler
+// is set requests are dispatched directly to it instead
+// of over the network. Links to paths matching an Exclude
+// pattern are not followed.
 type Crawler struct {
 	URL         *url.URL
 	Concurrency int
 	Allow404    bool
+	Status      []StatusPolicy
+	Exclude     []string
 	HTTPClient  *http.Client
+	Handler     http.Handler
 
+	policies   []policy
+	exclude    []*glob.Pattern
+	queued     int64
 	pending    sync.WaitGroup
 	resources  chan Resource
 	targets    chan Target
 	duplicates deduplicator.Deduplicator
+	ctx        context.Context
 	done       chan struct{}
+	err        error
 }
 
 // Run starts the crawling process and waits for completion.
@@ -75,27 +116,63 @@ func (c *Crawler) Start(ctx context.Context) error {
 		c.Concurrency = 1
 	}
 
+	if c.Handler != nil {
+		c.HTTPClient = &http.Client{
+			Transport: HandlerTransport{Handler: c.Handler},
+		}
+	}
+
 	if c.HTTPClient == nil {
 		c.HTTPClient = http.DefaultClient
 	}
 
+	// status policies
+	for _, s := range c.Status {
+		p, err := glob.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("compiling status policy: %w", err)
+		}
+		c.policies = append(c.policies, policy{StatusPolicy: s, pattern: p})
+	}
+
+	// exclude patterns
+	for _, s := range c.Exclude {
+		p, err := glob.Compile(s)
+		if err != nil {
+			return fmt.Errorf("compiling exclude pattern: %w", err)
+		}
+		c.exclude = append(c.exclude, p)
+	}
+
 	// setup
 	c.resources = make(chan Resource)
 	c.targets = make(chan Target)
 	c.done = make(chan struct{})
 
+	ctx, cancel := context.WithCancel(ctx)
+	c.ctx = ctx
+
 	// initial page
 	c.Queue(c.URL)
 
 	// start workers
-	ctx, cancel := context.WithCancel(ctx)
 	for i := 0; i < c.Concurrency; i++ {
 		go c.crawl(ctx)
 	}
 
-	// wait for completion
+	// wait for completion or cancellation
+	idle := make(chan struct{})
 	go func() {
 		c.pending.Wait()
+		close(idle)
+	}()
+
+	go func() {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			c.err = ctx.Err()
+		}
 		cancel()
 		close(c.done)
 	}()
@@ -103,18 +180,31 @@ func (c *Crawler) Start(ctx context.Context) error {
 	return nil
 }
 
-// Queue a given URL. This method is non-blocking.
+// Queue a given URL, unless it was already queued. This method is non-blocking.
 func (c *Crawler) Queue(u *url.URL) {
-	c.pending.Add(1)
+	if len(c.duplicates.Filter([]*url.URL{u})) == 0 {
+		return
+	}
+
+	c.add(1)
 	go func() {
-		c.targets <- Target{URL: u}
+		select {
+		case c.targets <- Target{URL: u}:
+		case <-c.ctx.Done():
+		}
 	}()
 }
 
-// Wait for all pending targets to be crawled.
+// Queued returns the number of targets queued which have not been visited.
+func (c *Crawler) Queued() int {
+	return int(atomic.LoadInt64(&c.queued))
+}
+
+// Wait for all pending targets to be crawled, returning
+// the context error if the context is done beforehand.
 func (c *Crawler) Wait() error {
 	<-c.done
-	return nil
+	return c.err
 }
 
 // Resources returns a channel of resources visited by the crawler.
@@ -137,7 +227,7 @@ func (c *Crawler) crawl(ctx context.Context) {
 				r.Error = err
 				select {
 				case c.resources <- r:
-					c.pending.Done()
+					c.finish()
 				case <-ctx.Done():
 					return
 				}
@@ -145,14 +235,16 @@ func (c *Crawler) crawl(ctx context.Context) {
 			}
 
 			// queue urls
+			urls = c.filter(urls)
+			r.Links = urls
 			urls = c.duplicates.Filter(urls)
-			c.pending.Add(len(urls))
+			c.add(len(urls))
 			go c.queue(urls, t)
 
 			// send resource
 			select {
 			case c.resources <- r:
-				c.pending.Done()
+				c.finish()
 			case <-ctx.Done():
 				return
 			}
@@ -160,6 +252,18 @@ func (c *Crawler) crawl(ctx context.Context) {
 	}
 }
 
+// add n pending targets.
+func (c *Crawler) add(n int) {
+	atomic.AddInt64(&c.queued, int64(n))
+	c.pending.Add(n)
+}
+
+// finish a pending target.
+func (c *Crawler) finish() {
+	atomic.AddInt64(&c.queued, -1)
+	c.pending.Done()
+}
+
 // visit a target and return any additional targets to crawl.
 func (c *Crawler) visit(ctx context.Context, t Target) ([]*url.URL, Resource, error) {
 	start := time.Now()
@@ -173,24 +277,41 @@ func (c *Crawler) visit(ctx context.Context, t Target) ([]*url.URL, Resource, er
 
 	req = req.WithContext(ctx)
 
+	// record redirects
+	client := *c.HTTPClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if c.HTTPClient.CheckRedirect != nil {
+			err := c.HTTPClient.CheckRedirect(req, via)
+			if err != nil {
+				return err
+			}
+		} else if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		r.Redirects = append(r.Redirects, req.URL)
+		return nil
+	}
+
 	// response
-	res, err := c.HTTPClient.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, r, err
 	}
 
 	r.StatusCode = res.StatusCode
+	r.Header = res.Header
 	r.Duration = time.Since(start)
 	r.Body = res.Body
 
-	// ignore 404s
-	if res.StatusCode == 404 && c.Allow404 {
-		return nil, r, nil
-	}
-
-	// http error
+	// http error, unless accepted
 	if res.StatusCode >= 300 {
-		return nil, r, fmt.Errorf("%s response", res.Status)
+		save, ok := c.accept(t.URL.Path, res.StatusCode)
+		if !ok {
+			return nil, r, fmt.Errorf("%s response", res.Status)
+		}
+		r.Discard = !save
+		return nil, r, nil
 	}
 
 	// file handling
@@ -214,12 +335,71 @@ func (c *Crawler) visit(ctx context.Context, t Target) ([]*url.URL, Resource, er
 	}
 }
 
+// accept returns true if the status code is accepted for the given path,
+// and whether the response body should be saved. The policy with the
+// longest matching pattern takes precedence, with ties broken by the
+// lexically smallest pattern.
+func (c *Crawler) accept(path string, status int) (save, ok bool) {
+	if path == "" {
+		path = "/"
+	}
+
+	var match *policy
+	for i, p := range c.policies {
+		if !p.pattern.Match(path) {
+			continue
+		}
+
+		if match == nil || precedes(p.Pattern, match.Pattern) {
+			match = &c.policies[i]
+		}
+	}
+
+	if match != nil {
+		for _, code := range match.Allow {
+			if code == status {
+				return match.Save, true
+			}
+		}
+	}
+
+	// ignore 404s
+	if status == 404 && c.Allow404 {
+		return true, true
+	}
+
+	return false, false
+}
+
+// precedes returns true if pattern a takes precedence over pattern b.
+func precedes(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a < b
+}
+
+// filter returns the urls which are not excluded.
+func (c *Crawler) filter(urls []*url.URL) (filtered []*url.URL) {
+outer:
+	for _, u := range urls {
+		for _, p := range c.exclude {
+			if p.Match(u.Path) {
+				continue outer
+			}
+		}
+		filtered = append(filtered, u)
+	}
+	return
+}
+
 // queue the given urls with parent target.
 func (c *Crawler) queue(urls []*url.URL, t Target) {
 	for _, u := range urls {
-		c.targets <- Target{
-			URL:    u,
-			Parent: t.URL,
+		select {
+		case c.targets <- Target{URL: u, Parent: t.URL}:
+		case <-c.ctx.Done():
+			return
 		}
 	}
 }
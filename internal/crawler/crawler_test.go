@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"testing"
 	"time"
 
@@ -15,6 +16,39 @@ import (
 	"github.com/tj/staticgen/internal/crawler"
 )
 
+// crawl runs the crawler to completion and returns the resources visited.
+func crawl(t testing.TB, c *crawler.Crawler) (resources []crawler.Resource) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	err := c.Start(ctx)
+	assert.NoError(t, err, "start")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case r := <-c.Resources():
+				if r.Body != nil {
+					io.Copy(ioutil.Discard, r.Body)
+					r.Body.Close()
+				}
+				resources = append(resources, r)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err = c.Wait()
+	assert.NoError(t, err, "wait")
+
+	cancel()
+	<-done
+	return
+}
+
 // Test .
 func TestCrawler(t *testing.T) {
 	u, _ := url.Parse("https://apex.sh")
@@ -50,3 +84,199 @@ func TestCrawler(t *testing.T) {
 
 	fmt.Printf("done\n")
 }
+
+// Test crawling an http.Handler.
+func TestCrawler_handler(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="/about">About</a><link rel="stylesheet" href="/style.css">`)
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="/">Home</a>`)
+	})
+	mux.HandleFunc("/style.css", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `body { color: red }`)
+	})
+
+	c := crawler.Crawler{
+		URL:         u,
+		Concurrency: 2,
+		Handler:     mux,
+	}
+
+	var paths []string
+	links := make(map[string][]string)
+	for _, r := range crawl(t, &c) {
+		assert.NoError(t, r.Error)
+		paths = append(paths, r.URL.Path)
+		for _, l := range r.Links {
+			links[r.URL.Path] = append(links[r.URL.Path], l.Path)
+		}
+	}
+
+	sort.Strings(paths)
+	assert.Equal(t, []string{"", "/about", "/style.css"}, paths)
+	assert.Equal(t, []string{"/about", "/style.css"}, links[""])
+	assert.Equal(t, []string{"/"}, links["/about"])
+	assert.Equal(t, 0, c.Queued())
+}
+
+// Test excluding paths.
+func TestCrawler_exclude(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="/about">About</a><a href="/drafts/post">Draft</a>`)
+	})
+
+	c := crawler.Crawler{
+		URL:         u,
+		Concurrency: 2,
+		Handler:     mux,
+		Exclude:     []string{"/drafts/*"},
+	}
+
+	var paths []string
+	for _, r := range crawl(t, &c) {
+		paths = append(paths, r.URL.Path)
+	}
+
+	assert.Contains(t, paths, "/about")
+	assert.NotContains(t, paths, "/drafts/post")
+}
+
+// Test cancelling the context mid-crawl.
+func TestCrawler_cancel(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="/slow">Slow</a>`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	c := crawler.Crawler{
+		URL:         u,
+		Concurrency: 2,
+		Handler:     mux,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := c.Start(ctx)
+	assert.NoError(t, err, "start")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the crawler to stop")
+	}
+}
+
+// Test recording redirects.
+func TestCrawler_redirects(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="/old">Old</a>`)
+	})
+	mux.Handle("/old", http.RedirectHandler("/older", http.StatusMovedPermanently))
+	mux.Handle("/older", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `New`)
+	})
+
+	c := crawler.Crawler{
+		URL:         u,
+		Concurrency: 2,
+		Handler:     mux,
+	}
+
+	var redirects []string
+	for _, r := range crawl(t, &c) {
+		if r.URL.Path == "/old" {
+			for _, u := range r.Redirects {
+				redirects = append(redirects, u.Path)
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"/older", "/new"}, redirects)
+}
+
+// Test accepting non-2xx responses with status policies.
+func TestCrawler_status(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<a href="/legacy/a"></a><a href="/legacy/keep/b"></a><a href="/tie/c"></a><a href="/missing"></a><a href="/gone"></a>`)
+		case "/gone", "/tie/c":
+			http.Error(w, "Gone", http.StatusGone)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	c := crawler.Crawler{
+		URL:         u,
+		Concurrency: 4,
+		Handler:     mux,
+		Allow404:    true,
+		Status: []crawler.StatusPolicy{
+			{Pattern: "/legacy/*", Allow: []int{404}, Save: false},
+			{Pattern: "/legacy/keep/*", Allow: []int{404}, Save: true},
+			{Pattern: "/tie/*", Allow: []int{410}, Save: false},
+			{Pattern: "/ti?/*", Allow: []int{410}, Save: true},
+		},
+	}
+
+	resources := make(map[string]crawler.Resource)
+	for _, r := range crawl(t, &c) {
+		resources[r.URL.Path] = r
+	}
+
+	t.Run("matching pattern", func(t *testing.T) {
+		r := resources["/legacy/a"]
+		assert.NoError(t, r.Error)
+		assert.True(t, r.Discard, "discard")
+	})
+
+	t.Run("longest pattern", func(t *testing.T) {
+		r := resources["/legacy/keep/b"]
+		assert.NoError(t, r.Error)
+		assert.False(t, r.Discard, "discard")
+	})
+
+	t.Run("equal length patterns", func(t *testing.T) {
+		r := resources["/tie/c"]
+		assert.NoError(t, r.Error)
+		assert.False(t, r.Discard, "discard")
+	})
+
+	t.Run("allow 404", func(t *testing.T) {
+		r := resources["/missing"]
+		assert.NoError(t, r.Error)
+		assert.False(t, r.Discard, "discard")
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		r := resources["/gone"]
+		assert.EqualError(t, r.Error, "410 Gone response")
+	})
+}
@@ -0,0 +1,41 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+// HandlerTransport is an http.RoundTripper which dispatches requests
+// directly to an http.Handler, without any networking.
+type HandlerTransport struct {
+	Handler http.Handler
+}
+
+// RoundTrip implementation.
+func (t HandlerTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	r := req.Clone(req.Context())
+	r.RequestURI = req.URL.RequestURI()
+	r.RemoteAddr = "127.0.0.1:0"
+
+	if r.Host == "" {
+		r.Host = req.URL.Host
+	}
+
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("handler panic: %v", v)
+		}
+	}()
+
+	w := httptest.NewRecorder()
+	t.Handler.ServeHTTP(w, r)
+
+	res = w.Result()
+	res.Request = req
+	return res, nil
+}
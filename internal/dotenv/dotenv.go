@@ -0,0 +1,118 @@
+// Package dotenv provides parsing of ".env" files.
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// keyRe is the regexp used for validating variable names.
+var keyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// A Var is an environment variable.
+type Var struct {
+	Key   string
+	Value string
+}
+
+// Parse returns the variables in r, in order of appearance. Lines are in the
+// form KEY=VALUE, optionally prefixed with "export", and blank lines or
+// lines starting with "#" are ignored. Unquoted and double-quoted values
+// may reference variables using ${VAR} or $VAR, resolved from previous
+// variables in the file, then getenv. Single-quoted values are literal.
+func Parse(r io.Reader, getenv func(string) string) ([]Var, error) {
+	var vars []Var
+	seen := make(map[string]string)
+
+	lookup := func(key string) string {
+		if v, ok := seen[key]; ok {
+			return v
+		}
+		return getenv(key)
+	}
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("line %d: missing '='", n)
+		}
+
+		key := strings.TrimSpace(line[:i])
+		if !keyRe.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", n, key)
+		}
+
+		value, err := parseValue(strings.TrimSpace(line[i+1:]), lookup)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		seen[key] = value
+		vars = append(vars, Var{Key: key, Value: value})
+	}
+
+	return vars, s.Err()
+}
+
+// Load returns the variables in the file at path.
+func Load(path string, getenv func(string) string) ([]Var, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f, getenv)
+}
+
+// parseValue returns the value, unquoted and expanded.
+func parseValue(s string, lookup func(string) string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end == -1 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return s[1 : end+1], nil
+	case strings.HasPrefix(s, `"`):
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '"':
+				return os.Expand(b.String(), lookup), nil
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case '"', '\\':
+					b.WriteByte(s[i])
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	default:
+		if i := strings.Index(s, " #"); i != -1 {
+			s = strings.TrimSpace(s[:i])
+		}
+		return os.Expand(s, lookup), nil
+	}
+}
@@ -0,0 +1,60 @@
+package dotenv_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/dotenv"
+)
+
+// getenv used for testing.
+func getenv(key string) string {
+	if key == "HOME" {
+		return "/home/tj"
+	}
+	return ""
+}
+
+// Test parsing.
+func TestParse(t *testing.T) {
+	vars, err := dotenv.Parse(strings.NewReader(`
+# comment
+NODE_ENV=production
+export PORT=3000 # inline comment
+CACHE=${HOME}/cache
+ADDR="localhost:${PORT}"
+LITERAL='${PORT}'
+QUOTED="say \"hi\""
+BACKSLASH="a\\"
+ESCAPES="a\\\"b\nc\t"
+`), getenv)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []dotenv.Var{
+		{Key: "NODE_ENV", Value: "production"},
+		{Key: "PORT", Value: "3000"},
+		{Key: "CACHE", Value: "/home/tj/cache"},
+		{Key: "ADDR", Value: "localhost:3000"},
+		{Key: "LITERAL", Value: "${PORT}"},
+		{Key: "QUOTED", Value: `say "hi"`},
+		{Key: "BACKSLASH", Value: `a\`},
+		{Key: "ESCAPES", Value: "a\\\"b\nc\\t"},
+	}, vars)
+}
+
+// Test parsing errors.
+func TestParse_errors(t *testing.T) {
+	_, err := dotenv.Parse(strings.NewReader("FOO=bar\nnope\n"), getenv)
+	assert.EqualError(t, err, "line 2: missing '='")
+
+	_, err = dotenv.Parse(strings.NewReader("1FOO=bar"), getenv)
+	assert.EqualError(t, err, `line 1: invalid variable name "1FOO"`)
+
+	_, err = dotenv.Parse(strings.NewReader(`FOO="bar`), getenv)
+	assert.EqualError(t, err, "line 1: unterminated double-quoted value")
+
+	_, err = dotenv.Parse(strings.NewReader(`FOO="bar\"`), getenv)
+	assert.EqualError(t, err, "line 1: unterminated double-quoted value")
+}
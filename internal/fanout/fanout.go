@@ -0,0 +1,100 @@
+// Package fanout provides non-blocking delivery of values to multiple
+// subscribers, each with its own buffer, dropping values rather than
+// blocking the publisher when a subscriber falls behind.
+package fanout
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Fanout delivers published values to subscribers.
+type Fanout struct {
+	mu     sync.RWMutex
+	subs   []*Subscription
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Subscription is a subscriber's buffered delivery.
+type Subscription struct {
+	ch      chan interface{}
+	dropped int64
+}
+
+// Dropped returns the number of values dropped because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Subscribe calls fn with each value published, in order, from its own
+// goroutine, buffering up to size values before dropping them.
+func (f *Fanout) Subscribe(fn func(interface{}), size int) *Subscription {
+	s := &Subscription{
+		ch: make(chan interface{}, size),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		close(s.ch)
+		return s
+	}
+
+	f.subs = append(f.subs, s)
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for v := range s.ch {
+			fn(v)
+		}
+	}()
+
+	return s
+}
+
+// Publish a value to all subscribers without blocking.
+// Values published after Close are ignored.
+func (f *Fanout) Publish(v interface{}) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return
+	}
+
+	for _, s := range f.subs {
+		select {
+		case s.ch <- v:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	}
+}
+
+// Close the subscriptions and wait for buffered values to be delivered.
+func (f *Fanout) Close() {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		for _, s := range f.subs {
+			close(s.ch)
+		}
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+}
+
+// Dropped returns the total number of values dropped by all subscriptions.
+func (f *Fanout) Dropped() (n int64) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, s := range f.subs {
+		n += s.Dropped()
+	}
+
+	return
+}
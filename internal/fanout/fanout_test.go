@@ -0,0 +1,55 @@
+package fanout_test
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/fanout"
+)
+
+// Test delivery to multiple subscribers.
+func TestFanout(t *testing.T) {
+	var f fanout.Fanout
+	var a, b []interface{}
+
+	f.Subscribe(func(v interface{}) { a = append(a, v) }, 10)
+	f.Subscribe(func(v interface{}) { b = append(b, v) }, 10)
+
+	f.Publish(1)
+	f.Publish(2)
+	f.Publish(3)
+	f.Close()
+
+	assert.Equal(t, []interface{}{1, 2, 3}, a)
+	assert.Equal(t, []interface{}{1, 2, 3}, b)
+	assert.Equal(t, int64(0), f.Dropped())
+
+	f.Publish(4)
+	assert.Len(t, a, 3)
+}
+
+// Test dropping values for a slow subscriber.
+func TestFanout_dropped(t *testing.T) {
+	var f fanout.Fanout
+	var fast, slow []interface{}
+	block := make(chan struct{})
+
+	f.Subscribe(func(v interface{}) { fast = append(fast, v) }, 10)
+	s := f.Subscribe(func(v interface{}) {
+		<-block
+		slow = append(slow, v)
+	}, 2)
+
+	for i := 0; i < 10; i++ {
+		f.Publish(i)
+	}
+
+	close(block)
+	f.Close()
+
+	assert.Len(t, fast, 10)
+	assert.Equal(t, int64(10-len(slow)), s.Dropped())
+	assert.True(t, s.Dropped() >= 7, "dropped")
+	assert.Equal(t, s.Dropped(), f.Dropped())
+}
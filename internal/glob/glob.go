@@ -0,0 +1,80 @@
+// Package glob provides path pattern matching.
+package glob
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A Pattern is a compiled glob pattern, where "*" matches any sequence
+// of characters including "/", "?" matches any single character, and
+// "[...]" or "[!...]" matches a character class.
+type Pattern struct {
+	s  string
+	re *regexp.Regexp
+}
+
+// Compile returns a pattern, or an error if the syntax is invalid.
+func Compile(s string) (*Pattern, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(s[i+1:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated character class in %q", s)
+			}
+
+			class := s[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			n := strings.IndexAny(s[i:], "*?[")
+			if n == -1 {
+				n = len(s) - i
+			}
+
+			b.WriteString(regexp.QuoteMeta(s[i : i+n]))
+			i += n - 1
+		}
+	}
+
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+
+	return &Pattern{s: s, re: re}, nil
+}
+
+// MustCompile returns a pattern, panicking if the syntax is invalid.
+func MustCompile(s string) *Pattern {
+	p, err := Compile(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Match returns true if the pattern matches s.
+func (p *Pattern) Match(s string) bool {
+	return p.re.MatchString(s)
+}
+
+// String implementation.
+func (p *Pattern) String() string {
+	return p.s
+}
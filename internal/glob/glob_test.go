@@ -0,0 +1,48 @@
+package glob_test
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/glob"
+)
+
+// Test matching.
+func TestPattern_Match(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/legacy/*", "/legacy/foo", true},
+		{"/legacy/*", "/legacy/foo/bar", true},
+		{"/legacy/*", "/legacy", false},
+		{"/legacy/*", "/blog/legacy/foo", false},
+		{"/posts/?", "/posts/1", true},
+		{"/posts/?", "/posts/12", false},
+		{"/posts/[0-9]", "/posts/5", true},
+		{"/posts/[!0-9]", "/posts/5", false},
+		{"*.html", "/about.html", true},
+		{"/a.b", "/axb", false},
+		{"/café*", "/café/menu", true},
+		{"/café*", "/cafe/menu", false},
+		{"/caf?", "/café", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pattern+" "+c.path, func(t *testing.T) {
+			p := glob.MustCompile(c.pattern)
+			assert.Equal(t, c.match, p.Match(c.path))
+		})
+	}
+}
+
+// Test invalid syntax.
+func TestCompile_invalid(t *testing.T) {
+	_, err := glob.Compile("/posts/[0-9")
+	assert.EqualError(t, err, `unterminated character class in "/posts/[0-9"`)
+
+	_, err = glob.Compile("/posts/[9-0]")
+	assert.Error(t, err)
+}
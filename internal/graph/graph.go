@@ -0,0 +1,193 @@
+// Package graph provides output of the link graph discovered while crawling.
+package graph
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A Node is a crawled resource in the graph, with an
+// edge to each of the URLs in Links.
+type Node struct {
+	URL         string
+	Links       []string
+	StatusCode  int
+	ContentType string
+	Size        int64
+	Duration    time.Duration
+	Error       string
+}
+
+// jsonNode is the JSON representation of a node.
+type jsonNode struct {
+	URL         string  `json:"url"`
+	StatusCode  int     `json:"status"`
+	ContentType string  `json:"type,omitempty"`
+	Size        int64   `json:"size"`
+	Duration    float64 `json:"duration_ms"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// edge is a link from one URL to another.
+type edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// WriteJSON writes the nodes and edges as a JSON object.
+func WriteJSON(w io.Writer, nodes []Node) error {
+	var v struct {
+		Nodes []jsonNode `json:"nodes"`
+		Edges []edge     `json:"edges"`
+	}
+
+	v.Nodes = []jsonNode{}
+	v.Edges = edges(nodes)
+
+	for _, n := range sorted(nodes) {
+		v.Nodes = append(v.Nodes, jsonNode{
+			URL:         n.URL,
+			StatusCode:  n.StatusCode,
+			ContentType: n.ContentType,
+			Size:        n.Size,
+			Duration:    milliseconds(n.Duration),
+			Error:       n.Error,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteDOT writes the nodes and edges in the GraphViz DOT language.
+func WriteDOT(w io.Writer, nodes []Node) error {
+	nodes = sorted(nodes)
+
+	_, err := fmt.Fprintf(w, "digraph staticgen {\n")
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		_, err := fmt.Fprintf(w, "  %s [status=%d, type=%s, size=%d, duration=%s",
+			strconv.Quote(n.URL),
+			n.StatusCode,
+			strconv.Quote(n.ContentType),
+			n.Size,
+			strconv.Quote(n.Duration.Round(time.Millisecond).String()))
+		if err != nil {
+			return err
+		}
+
+		if n.Error != "" {
+			_, err = fmt.Fprintf(w, ", error=%s, color=red", strconv.Quote(n.Error))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = fmt.Fprintf(w, "];\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range edges(nodes) {
+		_, err := fmt.Fprintf(w, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "}\n")
+	return err
+}
+
+// WriteCSV writes the nodes as CSV, one row per incoming edge of each
+// node with the parent URL linking to it, or a single row with an empty
+// parent for nodes without incoming edges.
+func WriteCSV(w io.Writer, nodes []Node) error {
+	c := csv.NewWriter(w)
+
+	err := c.Write([]string{"url", "parent", "status", "type", "size", "duration_ms", "error"})
+	if err != nil {
+		return err
+	}
+
+	parents := make(map[string][]string)
+	for _, e := range edges(nodes) {
+		parents[e.To] = append(parents[e.To], e.From)
+	}
+
+	for _, n := range sorted(nodes) {
+		from := parents[n.URL]
+		if len(from) == 0 {
+			from = []string{""}
+		}
+
+		for _, parent := range from {
+			err := c.Write([]string{
+				n.URL,
+				parent,
+				strconv.Itoa(n.StatusCode),
+				n.ContentType,
+				strconv.FormatInt(n.Size, 10),
+				strconv.FormatFloat(milliseconds(n.Duration), 'f', -1, 64),
+				n.Error,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	c.Flush()
+	return c.Error()
+}
+
+// edges returns the unique edges of the nodes, sorted by URL.
+func edges(nodes []Node) []edge {
+	seen := make(map[edge]bool)
+	edges := []edge{}
+
+	for _, n := range nodes {
+		for _, l := range n.Links {
+			e := edge{From: n.URL, To: l}
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+
+	return edges
+}
+
+// sorted returns a copy of nodes sorted by URL.
+func sorted(nodes []Node) []Node {
+	s := make([]Node, len(nodes))
+	copy(s, nodes)
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].URL < s[j].URL
+	})
+	return s
+}
+
+// milliseconds returns d in milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,95 @@
+package graph_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/graph"
+)
+
+// nodes used for testing.
+var nodes = []graph.Node{
+	{
+		URL:         "http://localhost/about",
+		Links:       []string{"http://localhost/contact", "http://localhost/"},
+		StatusCode:  200,
+		ContentType: "text/html",
+		Size:        512,
+		Duration:    5 * time.Millisecond,
+	},
+	{
+		URL:         "http://localhost/",
+		Links:       []string{"http://localhost/about", "http://localhost/contact", "http://localhost/about"},
+		StatusCode:  200,
+		ContentType: "text/html",
+		Size:        1024,
+		Duration:    10 * time.Millisecond,
+	},
+	{
+		URL:         "http://localhost/contact",
+		StatusCode:  200,
+		ContentType: "text/html",
+		Size:        256,
+		Duration:    2 * time.Millisecond,
+	},
+}
+
+// Test JSON output.
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := graph.WriteJSON(&buf, nodes)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"url": "http://localhost/"`)
+	assert.Contains(t, buf.String(), `"duration_ms": 10`)
+	assert.Contains(t, buf.String(), `"edges": [
+    {
+      "from": "http://localhost/",
+      "to": "http://localhost/about"
+    },
+    {
+      "from": "http://localhost/",
+      "to": "http://localhost/contact"
+    },
+    {
+      "from": "http://localhost/about",
+      "to": "http://localhost/"
+    },
+    {
+      "from": "http://localhost/about",
+      "to": "http://localhost/contact"
+    }
+  ]`)
+}
+
+// Test DOT output.
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	err := graph.WriteDOT(&buf, nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph staticgen {
+  "http://localhost/" [status=200, type="text/html", size=1024, duration="10ms"];
+  "http://localhost/about" [status=200, type="text/html", size=512, duration="5ms"];
+  "http://localhost/contact" [status=200, type="text/html", size=256, duration="2ms"];
+  "http://localhost/" -> "http://localhost/about";
+  "http://localhost/" -> "http://localhost/contact";
+  "http://localhost/about" -> "http://localhost/";
+  "http://localhost/about" -> "http://localhost/contact";
+}
+`, buf.String())
+}
+
+// Test CSV output.
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := graph.WriteCSV(&buf, nodes)
+	assert.NoError(t, err)
+	assert.Equal(t, `url,parent,status,type,size,duration_ms,error
+http://localhost/,http://localhost/about,200,text/html,1024,10,
+http://localhost/about,http://localhost/,200,text/html,512,5,
+http://localhost/contact,http://localhost/,200,text/html,256,2,
+http://localhost/contact,http://localhost/about,200,text/html,256,2,
+`, buf.String())
+}
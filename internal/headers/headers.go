@@ -0,0 +1,168 @@
+// Package headers provides export of response headers for static website hosts.
+package headers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// A Rule is a set of response headers for a single file.
+type Rule struct {
+	// Path is the URL path of the resource.
+	Path string
+
+	// File is the slash-separated path of the file relative to the output directory.
+	File string
+
+	// Header is the set of headers to serve with the file.
+	Header http.Header
+}
+
+// s3Headers is a map of headers to their S3 system metadata names,
+// any other headers are written as user-defined metadata.
+var s3Headers = map[string]string{
+	"Cache-Control":       "CacheControl",
+	"Content-Disposition": "ContentDisposition",
+	"Content-Encoding":    "ContentEncoding",
+	"Content-Language":    "ContentLanguage",
+	"Content-Type":        "ContentType",
+	"Expires":             "Expires",
+}
+
+// Filter returns a copy of h containing only the allowed header names.
+func Filter(h http.Header, allow []string) http.Header {
+	f := make(http.Header)
+	for _, name := range allow {
+		if v, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			f[http.CanonicalHeaderKey(name)] = v
+		}
+	}
+	return f
+}
+
+// WriteNetlify writes rules in the "_headers" file format
+// used by Netlify and Cloudflare Pages.
+func WriteNetlify(w io.Writer, rules []Rule) error {
+	for _, r := range sorted(rules) {
+		_, err := fmt.Fprintf(w, "%s\n", r.Path)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names(r.Header) {
+			_, err := fmt.Fprintf(w, "  %s: %s\n", name, value(r.Header, name))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteNginx writes rules as nginx location blocks, suitable for use with
+// the include directive in a server block. Locations match the file path
+// so that they apply after nginx resolves index files.
+func WriteNginx(w io.Writer, rules []Rule) error {
+	for _, r := range sorted(rules) {
+		_, err := fmt.Fprintf(w, "location = /%s {\n", r.File)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names(r.Header) {
+			v := quote(value(r.Header, name))
+
+			if name == "Content-Type" {
+				_, err = fmt.Fprintf(w, "  types { }\n  default_type %s;\n", v)
+			} else {
+				_, err = fmt.Fprintf(w, "  add_header %s %s;\n", name, v)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = fmt.Fprintf(w, "}\n\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteS3 writes rules as a JSON object mapping S3 object keys to their
+// metadata, using the PutObject parameter names for system metadata
+// such as "ContentType", and "Metadata" for user-defined metadata.
+func WriteS3(w io.Writer, rules []Rule) error {
+	objects := make(map[string]map[string]interface{})
+
+	for _, r := range rules {
+		if len(r.Header) == 0 {
+			continue
+		}
+
+		o := make(map[string]interface{})
+		meta := make(map[string]string)
+
+		for _, name := range names(r.Header) {
+			if param, ok := s3Headers[name]; ok {
+				o[param] = value(r.Header, name)
+				continue
+			}
+			meta[strings.ToLower(name)] = value(r.Header, name)
+		}
+
+		if len(meta) > 0 {
+			o["Metadata"] = meta
+		}
+
+		objects[r.File] = o
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+// sorted returns a copy of rules with headers, sorted by path.
+func sorted(rules []Rule) (s []Rule) {
+	for _, r := range rules {
+		if len(r.Header) > 0 {
+			s = append(s, r)
+		}
+	}
+
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].Path < s[j].Path
+	})
+
+	return
+}
+
+// names returns the sorted header names of h.
+func names(h http.Header) (s []string) {
+	for name := range h {
+		s = append(s, name)
+	}
+	sort.Strings(s)
+	return
+}
+
+// value returns the header field values joined by commas.
+func value(h http.Header, name string) string {
+	return strings.Join(h[name], ", ")
+}
+
+// quote returns an nginx quoted string.
+func quote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,96 @@
+package headers_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/headers"
+)
+
+// rules used for testing.
+var rules = []headers.Rule{
+	{
+		Path: "/about",
+		File: "about/index.html",
+		Header: http.Header{
+			"Cache-Control":   {"max-age=60"},
+			"X-Frame-Options": {"DENY"},
+		},
+	},
+	{
+		Path: "/",
+		File: "index.html",
+		Header: http.Header{
+			"Content-Type": {"text/html"},
+		},
+	},
+	{
+		Path:   "/style.css",
+		File:   "style.css",
+		Header: http.Header{},
+	},
+}
+
+// Test filtering.
+func TestFilter(t *testing.T) {
+	h := http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Date":          {"Mon, 01 Jan 2020 00:00:00 GMT"},
+	}
+
+	f := headers.Filter(h, []string{"cache-control", "X-Frame-Options"})
+	assert.Equal(t, http.Header{"Cache-Control": {"max-age=60"}}, f)
+}
+
+// Test Netlify output.
+func TestWriteNetlify(t *testing.T) {
+	var buf bytes.Buffer
+	err := headers.WriteNetlify(&buf, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, `/
+  Content-Type: text/html
+/about
+  Cache-Control: max-age=60
+  X-Frame-Options: DENY
+`, buf.String())
+}
+
+// Test nginx output.
+func TestWriteNginx(t *testing.T) {
+	var buf bytes.Buffer
+	err := headers.WriteNginx(&buf, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, `location = /index.html {
+  types { }
+  default_type "text/html";
+}
+
+location = /about/index.html {
+  add_header Cache-Control "max-age=60";
+  add_header X-Frame-Options "DENY";
+}
+
+`, buf.String())
+}
+
+// Test S3 output.
+func TestWriteS3(t *testing.T) {
+	var buf bytes.Buffer
+	err := headers.WriteS3(&buf, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "about/index.html": {
+    "CacheControl": "max-age=60",
+    "Metadata": {
+      "x-frame-options": "DENY"
+    }
+  },
+  "index.html": {
+    "ContentType": "text/html"
+  }
+}
+`, buf.String())
+}
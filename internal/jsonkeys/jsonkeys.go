@@ -0,0 +1,272 @@
+// Package jsonkeys provides detection of unknown keys in JSON documents.
+package jsonkeys
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// A Key is an unknown key in a JSON document.
+type Key struct {
+	// Path is the dot-separated path of the key, such as "ready.tiemout".
+	Path string
+
+	// Line is the line number of the key, starting at 1.
+	Line int
+
+	// Column is the column number of the key, starting at 1.
+	Column int
+
+	// Suggestion is the most similar known key, if any.
+	Suggestion string
+}
+
+// Unknown returns the keys in the JSON document b which do not correspond
+// to a field of v, a struct or pointer to a struct, following the field
+// naming rules of encoding/json.
+func Unknown(b []byte, v interface{}) ([]Key, error) {
+	w := walker{
+		b:   b,
+		dec: json.NewDecoder(bytes.NewReader(b)),
+	}
+
+	err := w.value(reflect.TypeOf(v), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return w.keys, nil
+}
+
+// walker walks a JSON document alongside a type.
+type walker struct {
+	b    []byte
+	dec  *json.Decoder
+	keys []Key
+}
+
+// value walks a value of type t, which is nil when unknown.
+func (w *walker) value(t reflect.Type, path string) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return w.object(t, path)
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+
+		for w.dec.More() {
+			err := w.value(elem, path+"[]")
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := w.dec.Token()
+		return err
+	default:
+		return nil
+	}
+}
+
+// object walks an object of type t, which is nil when unknown.
+func (w *walker) object(t reflect.Type, path string) error {
+	var fields map[string]reflect.Type
+	if t != nil && t.Kind() == reflect.Struct {
+		fields = structFields(t)
+	}
+
+	for w.dec.More() {
+		tok, err := w.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key := tok.(string)
+		end := w.dec.InputOffset()
+
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		var ft reflect.Type
+		switch {
+		case t != nil && t.Kind() == reflect.Map:
+			ft = t.Elem()
+		case fields != nil:
+			name, ok := lookup(fields, key)
+			if !ok {
+				line, col := Position(w.b, start(w.b, end))
+				w.keys = append(w.keys, Key{
+					Path:       keyPath,
+					Line:       line,
+					Column:     col,
+					Suggestion: suggest(fields, key),
+				})
+			}
+			ft = fields[name]
+		}
+
+		err = w.value(ft, keyPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.dec.Token()
+	return err
+}
+
+// structFields returns the JSON field names of struct type t and their types,
+// including the fields of embedded structs.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k, v := range structFields(f.Type) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+// lookup returns the field name matching key, case-insensitively
+// as encoding/json does.
+func lookup(fields map[string]reflect.Type, key string) (string, bool) {
+	if _, ok := fields[key]; ok {
+		return key, true
+	}
+
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// suggest returns the most similar field name to key, if any are similar enough.
+func suggest(fields map[string]reflect.Type, key string) string {
+	best := ""
+	min := len(key)/3 + 1
+	if min < 3 {
+		min = 3
+	}
+
+	for name := range fields {
+		d := distance(strings.ToLower(key), name)
+		if d < min || (d == min && best != "" && name < best) {
+			best = name
+			min = d
+		}
+	}
+
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// minInt returns the smallest of the given ints.
+func minInt(v ...int) int {
+	m := v[0]
+	for _, n := range v[1:] {
+		if n < m {
+			m = n
+		}
+	}
+	return m
+}
+
+// start returns the offset of the opening quote of
+// the key whose closing quote precedes offset end.
+func start(b []byte, end int64) int64 {
+	i := bytes.LastIndexByte(b[:end], '"')
+	for i > 0 {
+		j := bytes.LastIndexByte(b[:i], '"')
+		if j == -1 {
+			return 0
+		}
+
+		// skip escaped quotes
+		if j > 0 && b[j-1] == '\\' {
+			i = j
+			continue
+		}
+
+		return int64(j)
+	}
+
+	return 0
+}
+
+// Position returns the line and column of offset in b.
+func Position(b []byte, offset int64) (line, col int) {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+
+	line = 1 + bytes.Count(b[:offset], []byte("\n"))
+	col = int(offset) - bytes.LastIndexByte(b[:offset], '\n')
+	return
+}
@@ -0,0 +1,59 @@
+package jsonkeys_test
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/jsonkeys"
+)
+
+// Ready config used for testing.
+type Ready struct {
+	Timeout string `json:"timeout"`
+}
+
+// Embedded config used for testing.
+type Embedded struct {
+	Dir string `json:"dir"`
+}
+
+// Config used for testing.
+type Config struct {
+	Embedded
+	URL         string            `json:"url"`
+	Concurrency int               `json:"concurrency"`
+	Allow404    bool              `json:"allow_404"`
+	Ready       Ready             `json:"ready"`
+	Services    []Ready           `json:"services"`
+	Env         map[string]string `json:"env"`
+}
+
+// Test unknown keys.
+func TestUnknown(t *testing.T) {
+	b := []byte(`{
+  "url": "http://localhost",
+  "dir": "build",
+  "alow_404": true,
+  "CONCURRENCY": 5,
+  "ready": { "tiemout": "5s" },
+  "services": [{ "timeout": "1s" }, { "nope": 1 }],
+  "dri": "build",
+  "env": { "anything": "goes" }
+}`)
+
+	keys, err := jsonkeys.Unknown(b, &Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, []jsonkeys.Key{
+		{Path: "alow_404", Line: 4, Column: 3, Suggestion: "allow_404"},
+		{Path: "ready.tiemout", Line: 6, Column: 14, Suggestion: "timeout"},
+		{Path: "services[].nope", Line: 7, Column: 39},
+		{Path: "dri", Line: 8, Column: 3, Suggestion: "dir"},
+	}, keys)
+}
+
+// Test invalid JSON.
+func TestUnknown_invalid(t *testing.T) {
+	_, err := jsonkeys.Unknown([]byte(`{"url": }`), &Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+// Package junit provides JUnit XML report writing.
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// Seconds is a duration in seconds, formatted with millisecond precision.
+type Seconds float64
+
+// MarshalXMLAttr implementation.
+func (s Seconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{
+		Name:  name,
+		Value: strconv.FormatFloat(float64(s), 'f', 3, 64),
+	}, nil
+}
+
+// Failure is a test case failure.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// TestCase is a test case.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      Seconds  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+}
+
+// TestSuite is a group of test cases.
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Time      Seconds    `xml:"time,attr"`
+	TestCases []TestCase `xml:"testcase"`
+}
+
+// testSuites is the report root element.
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     Seconds     `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// Write a report with the given name and suites to w,
+// computing the test, failure and time totals.
+func Write(w io.Writer, name string, suites []TestSuite) error {
+	root := testSuites{
+		Name: name,
+	}
+
+	for _, s := range suites {
+		s.Tests = len(s.TestCases)
+		s.Failures = 0
+		s.Time = 0
+
+		for _, c := range s.TestCases {
+			s.Time += c.Time
+			if c.Failure != nil {
+				s.Failures++
+			}
+		}
+
+		root.Tests += s.Tests
+		root.Failures += s.Failures
+		root.Time += s.Time
+		root.Suites = append(root.Suites, s)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(root)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,55 @@
+package junit_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/junit"
+)
+
+// Test writing.
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := junit.Write(&buf, "staticgen", []junit.TestSuite{
+		{
+			Name: "/",
+			TestCases: []junit.TestCase{
+				{Name: "/", Classname: "/", Time: 0.5},
+				{Name: "/about", Classname: "/", Time: 0.25},
+			},
+		},
+		{
+			Name: "/docs",
+			TestCases: []junit.TestCase{
+				{
+					Name:      "/docs/missing",
+					Classname: "/docs",
+					Time:      0.25,
+					Failure: &junit.Failure{
+						Message: "404 Not Found response",
+						Type:    "status",
+						Text:    "Linked from http://localhost/docs",
+					},
+				},
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="staticgen" tests="3" failures="1" time="1.000">
+  <testsuite name="/" tests="2" failures="0" time="0.750">
+    <testcase name="/" classname="/" time="0.500"></testcase>
+    <testcase name="/about" classname="/" time="0.250"></testcase>
+  </testsuite>
+  <testsuite name="/docs" tests="1" failures="1" time="0.250">
+    <testcase name="/docs/missing" classname="/docs" time="0.250">
+      <failure message="404 Not Found response" type="status">Linked from http://localhost/docs</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
@@ -0,0 +1,42 @@
+// Package manifest provides a manifest of the files written by a build.
+package manifest
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// An Entry is a single file written by a build, from either
+// a crawled URL or a copied Source file.
+type Entry struct {
+	URL         string            `json:"url,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Path        string            `json:"path"`
+	ContentType string            `json:"type,omitempty"`
+	Size        int64             `json:"size"`
+	SHA256      string            `json:"sha256"`
+	StatusCode  int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// A Manifest is a list of files written by a build.
+type Manifest struct {
+	Files []Entry `json:"files"`
+}
+
+// Write the manifest as JSON, with files sorted by path.
+func Write(w io.Writer, entries []Entry) error {
+	m := Manifest{
+		Files: make([]Entry, len(entries)),
+	}
+
+	copy(m.Files, entries)
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
@@ -0,0 +1,57 @@
+package manifest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/manifest"
+)
+
+// Test writing.
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := manifest.Write(&buf, []manifest.Entry{
+		{
+			URL:    "http://localhost/style.css",
+			Path:   "style.css",
+			Size:   3,
+			SHA256: "abc",
+		},
+		{
+			URL:        "http://localhost/",
+			Path:       "index.html",
+			Size:       5,
+			SHA256:     "def",
+			StatusCode: 200,
+			Headers: map[string]string{
+				"Cache-Control": "no-cache",
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "files": [
+    {
+      "url": "http://localhost/",
+      "path": "index.html",
+      "size": 5,
+      "sha256": "def",
+      "status": 200,
+      "headers": {
+        "Cache-Control": "no-cache"
+      }
+    },
+    {
+      "url": "http://localhost/style.css",
+      "path": "style.css",
+      "size": 3,
+      "sha256": "abc"
+    }
+  ]
+}
+`, buf.String())
+}
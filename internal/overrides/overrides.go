@@ -0,0 +1,152 @@
+// Package overrides provides setting struct fields by their JSON key paths,
+// for overriding configuration with environment variables and flags.
+package overrides
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// unmarshaler is the reflected json.Unmarshaler interface.
+var unmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Keys returns the dot-separated JSON key paths of the fields of v,
+// a pointer to a struct, such as "url" or "ready.timeout". Nested
+// structs are expanded, while other types are a single key.
+func Keys(v interface{}) []string {
+	var keys []string
+	walk(reflect.TypeOf(v).Elem(), "", func(key string, _ []int) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Set the field of v, a pointer to a struct, at the given key path to
+// value. Strings are used as-is, numbers and booleans are parsed, string
+// slices are comma-separated, and other types are parsed as JSON.
+func Set(v interface{}, key, value string) error {
+	index := find(v, key)
+	if index == nil {
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	return set(reflect.ValueOf(v).Elem().FieldByIndex(index), value)
+}
+
+// Scalar returns true if the field of v, a pointer to a struct, at the
+// given key path is set from a plain value rather than JSON, see Set.
+func Scalar(v interface{}, key string) bool {
+	index := find(v, key)
+	if index == nil {
+		return false
+	}
+
+	t := reflect.TypeOf(v).Elem().FieldByIndex(index).Type
+	if reflect.PtrTo(t).Implements(unmarshaler) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+// find returns the field index of the key path in v, or nil.
+func find(v interface{}, key string) (index []int) {
+	walk(reflect.TypeOf(v).Elem(), "", func(k string, i []int) {
+		if k == key {
+			index = i
+		}
+	})
+	return
+}
+
+// walk the fields of struct type t, calling fn with each key path and
+// field index, expanding nested structs.
+func walk(t reflect.Type, prefix string, fn func(string, []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			walk(f.Type, prefix, func(k string, index []int) {
+				fn(k, append([]int{i}, index...))
+			})
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		if f.Type.Kind() == reflect.Struct && !reflect.PtrTo(f.Type).Implements(unmarshaler) {
+			walk(f.Type, prefix+name+".", func(k string, index []int) {
+				fn(k, append([]int{i}, index...))
+			})
+			continue
+		}
+
+		fn(prefix+name, []int{i})
+	}
+}
+
+// set the value of f by parsing s.
+func set(f reflect.Value, s string) error {
+	if reflect.PtrTo(f.Type()).Implements(unmarshaler) {
+		b, _ := json.Marshal(s)
+		return json.Unmarshal(b, f.Addr().Interface())
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		f.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		f.SetInt(v)
+	case reflect.Slice:
+		if f.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(s, "[") {
+			var v []string
+			if s != "" {
+				v = strings.Split(s, ",")
+			}
+			f.Set(reflect.ValueOf(v).Convert(f.Type()))
+			return nil
+		}
+		fallthrough
+	default:
+		v := reflect.New(f.Type())
+		err := json.Unmarshal([]byte(s), v.Interface())
+		if err != nil {
+			return fmt.Errorf("invalid JSON value: %w", err)
+		}
+		f.Set(v.Elem())
+	}
+
+	return nil
+}
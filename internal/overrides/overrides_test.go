@@ -0,0 +1,130 @@
+package overrides_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/overrides"
+)
+
+// duration used for testing.
+type duration time.Duration
+
+// UnmarshalJSON implementation.
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	*d = duration(v)
+	return err
+}
+
+// Base config used for testing.
+type Base struct {
+	Name string `json:"name"`
+}
+
+// config used for testing.
+type config struct {
+	Base
+	URL     string            `json:"url"`
+	Debug   bool              `json:"debug"`
+	Workers int               `json:"workers"`
+	Pages   []string          `json:"pages"`
+	Env     map[string]string `json:"env"`
+	Ready   struct {
+		Path    string   `json:"path"`
+		Timeout duration `json:"timeout"`
+	} `json:"ready"`
+	Ignored string `json:"-"`
+	private string
+}
+
+// Test listing keys.
+func TestKeys(t *testing.T) {
+	assert.Equal(t, []string{
+		"debug",
+		"env",
+		"name",
+		"pages",
+		"ready.path",
+		"ready.timeout",
+		"url",
+		"workers",
+	}, overrides.Keys(&config{}))
+}
+
+// Test setting fields.
+func TestSet(t *testing.T) {
+	var c config
+	set := func(key, value string) {
+		assert.NoError(t, overrides.Set(&c, key, value), key)
+	}
+
+	set("name", "site")
+	set("url", "http://localhost:5000")
+	set("debug", "true")
+	set("workers", "10")
+	set("pages", "/a,/b")
+	set("env", `{"NODE_ENV":"production"}`)
+	set("ready.path", "/health")
+	set("ready.timeout", "5s")
+
+	assert.Equal(t, "site", c.Name)
+	assert.Equal(t, "http://localhost:5000", c.URL)
+	assert.True(t, c.Debug)
+	assert.Equal(t, 10, c.Workers)
+	assert.Equal(t, []string{"/a", "/b"}, c.Pages)
+	assert.Equal(t, map[string]string{"NODE_ENV": "production"}, c.Env)
+	assert.Equal(t, "/health", c.Ready.Path)
+	assert.Equal(t, duration(5*time.Second), c.Ready.Timeout)
+
+	set("pages", `["/c,d"]`)
+	assert.Equal(t, []string{"/c,d"}, c.Pages)
+
+	set("pages", "")
+	assert.Nil(t, c.Pages)
+}
+
+// Test setting errors.
+func TestSet_errors(t *testing.T) {
+	var c config
+
+	err := overrides.Set(&c, "nope", "x")
+	assert.EqualError(t, err, `unknown key "nope"`)
+
+	err = overrides.Set(&c, "ready", "x")
+	assert.EqualError(t, err, `unknown key "ready"`)
+
+	err = overrides.Set(&c, "workers", "lots")
+	assert.EqualError(t, err, `invalid integer "lots"`)
+
+	err = overrides.Set(&c, "debug", "maybe")
+	assert.EqualError(t, err, `invalid boolean "maybe"`)
+
+	err = overrides.Set(&c, "env", "FOO=bar")
+	assert.True(t, strings.HasPrefix(err.Error(), "invalid JSON value: "))
+
+	err = overrides.Set(&c, "ready.timeout", "soon")
+	assert.Error(t, err)
+}
+
+// Test checking for plain values.
+func TestScalar(t *testing.T) {
+	c := &config{}
+	assert.True(t, overrides.Scalar(c, "url"))
+	assert.True(t, overrides.Scalar(c, "name"))
+	assert.True(t, overrides.Scalar(c, "debug"))
+	assert.True(t, overrides.Scalar(c, "workers"))
+	assert.True(t, overrides.Scalar(c, "pages"))
+	assert.True(t, overrides.Scalar(c, "ready.timeout"))
+	assert.False(t, overrides.Scalar(c, "env"))
+	assert.False(t, overrides.Scalar(c, "ready"))
+	assert.False(t, overrides.Scalar(c, "unknown"))
+}
@@ -0,0 +1,159 @@
+// Package probe provides server readiness probing.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// A Probe checks whether a server is ready to accept requests.
+type Probe struct {
+	// URL is the URL requested.
+	URL *url.URL
+
+	// Method is the request method. Defaults to "HEAD".
+	Method string
+
+	// Status is the expected response status code,
+	// when zero any response is accepted.
+	Status int
+
+	// Body is an optional substring expected in the response body.
+	Body string
+
+	// TCP can be enabled to only check that the
+	// server accepts connections, without a request.
+	TCP bool
+
+	// Socket is an optional Unix domain socket path, which
+	// is dialed instead of the URL host in TCP mode.
+	Socket string
+
+	// Interval is the time between checks. Defaults to one second.
+	Interval time.Duration
+
+	// Timeout is the maximum time to wait for readiness.
+	// Defaults to 15 seconds.
+	Timeout time.Duration
+
+	// HTTPClient used for requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Wait blocks until the server is ready, the timeout is exceeded,
+// or the context is cancelled. The first check is performed immediately.
+func (p *Probe) Wait(ctx context.Context) error {
+	interval := p.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		err := p.Check(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %s)", ctx.Err(), err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Check performs a single check, returning an error
+// describing why the server is not ready.
+func (p *Probe) Check(ctx context.Context) error {
+	if p.TCP {
+		return p.checkTCP(ctx)
+	}
+
+	return p.checkHTTP(ctx)
+}
+
+// checkTCP checks that the server accepts connections.
+func (p *Probe) checkTCP(ctx context.Context) error {
+	var d net.Dialer
+
+	if p.Socket != "" {
+		conn, err := d.DialContext(ctx, "unix", p.Socket)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	addr := p.URL.Host
+	if p.URL.Port() == "" {
+		port := "80"
+		if p.URL.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(p.URL.Hostname(), port)
+	}
+
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// checkHTTP checks the response to a request.
+func (p *Probe) checkHTTP(ctx context.Context) error {
+	method := p.Method
+	if method == "" {
+		method = "HEAD"
+	}
+
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(method, p.URL.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if p.Status != 0 && res.StatusCode != p.Status {
+		return fmt.Errorf("%s response, expected %d", res.Status, p.Status)
+	}
+
+	if p.Body == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(string(b), p.Body) {
+		return fmt.Errorf("response body does not contain %q", p.Body)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package probe_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/probe"
+)
+
+// Test HTTP checks.
+func TestProbe_Check(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintf(w, "Hello World")
+	}))
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	ctx := context.Background()
+
+	t.Run("any status", func(t *testing.T) {
+		p := probe.Probe{URL: u}
+		assert.NoError(t, p.Check(ctx))
+	})
+
+	t.Run("status mismatch", func(t *testing.T) {
+		p := probe.Probe{URL: u, Status: 200}
+		assert.EqualError(t, p.Check(ctx), "405 Method Not Allowed response, expected 200")
+	})
+
+	t.Run("status match", func(t *testing.T) {
+		p := probe.Probe{URL: u, Method: "GET", Status: 200}
+		assert.NoError(t, p.Check(ctx))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		p := probe.Probe{URL: u, Method: "GET", Body: "World"}
+		assert.NoError(t, p.Check(ctx))
+
+		p.Body = "Nope"
+		assert.EqualError(t, p.Check(ctx), `response body does not contain "Nope"`)
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		p := probe.Probe{URL: u, TCP: true}
+		assert.NoError(t, p.Check(ctx))
+	})
+}
+
+// Test TCP checks of a Unix domain socket.
+func TestProbe_Check_socket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "probe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.sock")
+	u, _ := url.Parse("http://localhost")
+	p := probe.Probe{URL: u, TCP: true, Socket: path}
+
+	assert.Error(t, p.Check(context.Background()))
+
+	l, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.NoError(t, p.Check(context.Background()))
+}
+
+// Test waiting for a server which never becomes ready.
+func TestProbe_Wait_timeout(t *testing.T) {
+	u, _ := url.Parse("http://127.0.0.1:1")
+
+	p := probe.Probe{
+		URL:      u,
+		TCP:      true,
+		Interval: 10 * time.Millisecond,
+		Timeout:  50 * time.Millisecond,
+	}
+
+	err := p.Wait(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "context deadline exceeded")
+}
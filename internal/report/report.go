@@ -0,0 +1,266 @@
+// Package report provides a self-contained HTML build report.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// largest is the number of largest resources listed.
+const largest = 20
+
+// buckets are the upper bounds of the timing histogram buckets.
+var buckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+}
+
+// Resource is a crawled resource.
+type Resource struct {
+	Site        string
+	URL         string
+	Parent      string
+	StatusCode  int
+	ContentType string
+	Size        int64
+	Duration    time.Duration
+	Error       string
+	Redirects   []string
+}
+
+// Report is the build report.
+type Report struct {
+	Title     string
+	Generated time.Time
+	Duration  time.Duration
+	Resources []Resource
+}
+
+// Bucket is a timing histogram bucket.
+type Bucket struct {
+	Label   string
+	Count   int
+	Percent float64
+}
+
+// view is the template data.
+type view struct {
+	Report
+	Sites     bool
+	Errors    []Resource
+	Redirects []Resource
+	Largest   []Resource
+	Histogram []Bucket
+	Bytes     int64
+}
+
+// Write the report as HTML to w.
+func Write(w io.Writer, r Report) error {
+	v := view{
+		Report:    r,
+		Histogram: histogram(r.Resources),
+	}
+
+	for _, res := range r.Resources {
+		v.Bytes += res.Size
+
+		if res.Site != "" {
+			v.Sites = true
+		}
+
+		if res.Error != "" {
+			v.Errors = append(v.Errors, res)
+		}
+
+		if len(res.Redirects) > 0 {
+			v.Redirects = append(v.Redirects, res)
+		}
+
+		if res.Error == "" {
+			v.Largest = append(v.Largest, res)
+		}
+	}
+
+	sort.SliceStable(v.Largest, func(i, j int) bool {
+		return v.Largest[i].Size > v.Largest[j].Size
+	})
+
+	if len(v.Largest) > largest {
+		v.Largest = v.Largest[:largest]
+	}
+
+	return page.Execute(w, v)
+}
+
+// histogram returns the timing histogram of resources which received a response.
+func histogram(resources []Resource) []Bucket {
+	h := make([]Bucket, len(buckets)+1)
+	for i, d := range buckets {
+		h[i].Label = "< " + d.String()
+	}
+	h[len(buckets)].Label = "≥ " + buckets[len(buckets)-1].String()
+
+	var total int
+	for _, r := range resources {
+		if r.StatusCode == 0 {
+			continue
+		}
+
+		i := sort.Search(len(buckets), func(i int) bool {
+			return r.Duration < buckets[i]
+		})
+
+		h[i].Count++
+		total++
+	}
+
+	for i := range h {
+		if total > 0 {
+			h[i].Percent = float64(h[i].Count) / float64(total) * 100
+		}
+	}
+
+	return h
+}
+
+// milliseconds returns a duration in milliseconds.
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
+
+// page is the report template.
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes":        func(n int64) string { return humanize.Bytes(uint64(n)) },
+	"milliseconds": milliseconds,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  body { font: 14px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; margin: 40px; }
+  h1 { font-size: 22px; margin: 0 0 4px; }
+  h2 { font-size: 17px; margin: 40px 0 10px; }
+  .meta { color: #777; margin-bottom: 20px; }
+  .summary span { display: inline-block; margin-right: 30px; }
+  .summary strong { display: block; font-size: 20px; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { text-align: left; padding: 4px 10px 4px 0; border-bottom: 1px solid #eee; vertical-align: top; }
+  th { cursor: pointer; user-select: none; white-space: nowrap; }
+  th.sorted::after { content: " ▾"; }
+  th.sorted.asc::after { content: " ▴"; }
+  td.num, th.num { text-align: right; }
+  .error { color: #c00; }
+  .empty { color: #777; }
+  .bar { background: #4a90e2; height: 12px; }
+  .histogram td:first-child { width: 80px; white-space: nowrap; }
+  .histogram td:last-child { width: 100%; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Generated {{.Generated.Format "Jan 2, 2006 15:04:05 MST"}} in {{.Duration}}</div>
+
+<div class="summary">
+  <span><strong>{{len .Resources}}</strong> resources</span>
+  <span><strong>{{len .Errors}}</strong> errors</span>
+  <span><strong>{{len .Redirects}}</strong> redirects</span>
+  <span><strong>{{bytes .Bytes}}</strong> total</span>
+</div>
+
+<h2>Errors</h2>
+{{if .Errors}}
+<table class="sortable">
+  <thead><tr>{{if .Sites}}<th>Site</th>{{end}}<th>URL</th><th>Referrer</th><th class="num">Status</th><th>Error</th></tr></thead>
+  <tbody>
+  {{range .Errors}}
+    <tr>{{if $.Sites}}<td>{{.Site}}</td>{{end}}<td>{{.URL}}</td><td>{{.Parent}}</td><td class="num">{{.StatusCode}}</td><td class="error">{{.Error}}</td></tr>
+  {{end}}
+  </tbody>
+</table>
+{{else}}
+<p class="empty">No errors.</p>
+{{end}}
+
+<h2>Pages</h2>
+<table class="sortable">
+  <thead><tr>{{if .Sites}}<th>Site</th>{{end}}<th>URL</th><th class="num">Status</th><th>Content type</th><th class="num">Size</th><th class="num">Duration (ms)</th></tr></thead>
+  <tbody>
+  {{range .Resources}}
+    <tr>{{if $.Sites}}<td>{{.Site}}</td>{{end}}<td>{{.URL}}</td><td class="num{{if .Error}} error{{end}}">{{.StatusCode}}</td><td>{{.ContentType}}</td><td class="num" data-value="{{.Size}}">{{bytes .Size}}</td><td class="num">{{milliseconds .Duration}}</td></tr>
+  {{end}}
+  </tbody>
+</table>
+
+<h2>Redirects</h2>
+{{if .Redirects}}
+<table class="sortable">
+  <thead><tr>{{if .Sites}}<th>Site</th>{{end}}<th>URL</th><th class="num">Hops</th><th>Chain</th></tr></thead>
+  <tbody>
+  {{range .Redirects}}
+    <tr>{{if $.Sites}}<td>{{.Site}}</td>{{end}}<td>{{.URL}}</td><td class="num">{{len .Redirects}}</td><td>{{range $i, $u := .Redirects}}{{if $i}}<br>{{end}}→ {{$u}}{{end}}</td></tr>
+  {{end}}
+  </tbody>
+</table>
+{{else}}
+<p class="empty">No redirects.</p>
+{{end}}
+
+<h2>Largest assets</h2>
+<table class="sortable">
+  <thead><tr>{{if .Sites}}<th>Site</th>{{end}}<th>URL</th><th>Content type</th><th class="num">Size</th></tr></thead>
+  <tbody>
+  {{range .Largest}}
+    <tr>{{if $.Sites}}<td>{{.Site}}</td>{{end}}<td>{{.URL}}</td><td>{{.ContentType}}</td><td class="num" data-value="{{.Size}}">{{bytes .Size}}</td></tr>
+  {{end}}
+  </tbody>
+</table>
+
+<h2>Response times</h2>
+<table class="histogram">
+  <tbody>
+  {{range .Histogram}}
+    <tr><td>{{.Label}}</td><td class="num">{{.Count}}</td><td><div class="bar" style="width: {{printf "%.1f" .Percent}}%"></div></td></tr>
+  {{end}}
+  </tbody>
+</table>
+
+<script>
+  document.querySelectorAll('table.sortable').forEach(function(table) {
+    table.querySelectorAll('th').forEach(function(th, i) {
+      th.addEventListener('click', function() {
+        var asc = !(th.classList.contains('sorted') && th.classList.contains('asc'));
+        table.querySelectorAll('th').forEach(function(h) { h.classList.remove('sorted', 'asc') });
+        th.classList.add('sorted');
+        if (asc) th.classList.add('asc');
+
+        var value = function(row) {
+          var cell = row.children[i];
+          var v = cell.dataset.value || cell.textContent;
+          var n = parseFloat(v);
+          return isNaN(n) ? v.toLowerCase() : n;
+        };
+
+        var tbody = table.tBodies[0];
+        Array.prototype.slice.call(tbody.rows).sort(function(a, b) {
+          var x = value(a), y = value(b);
+          var c = x < y ? -1 : x > y ? 1 : 0;
+          return asc ? c : -c;
+        }).forEach(function(row) { tbody.appendChild(row) });
+      });
+    });
+  });
+</script>
+</body>
+</html>
+`))
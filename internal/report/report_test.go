@@ -0,0 +1,64 @@
+package report_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/report"
+)
+
+// Test writing.
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := report.Write(&buf, report.Report{
+		Title:     "Staticgen report",
+		Generated: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:  time.Second,
+		Resources: []report.Resource{
+			{
+				URL:         "http://localhost/",
+				StatusCode:  200,
+				ContentType: "text/html",
+				Size:        2048,
+				Duration:    5 * time.Millisecond,
+			},
+			{
+				URL:        "http://localhost/old",
+				Parent:     "http://localhost/",
+				StatusCode: 200,
+				Size:       100,
+				Duration:   60 * time.Millisecond,
+				Redirects:  []string{"http://localhost/older", "http://localhost/new"},
+			},
+			{
+				URL:        "http://localhost/missing",
+				Parent:     "http://localhost/about",
+				StatusCode: 404,
+				Duration:   3 * time.Second,
+				Error:      "404 Not Found response",
+			},
+		},
+	})
+
+	assert.NoError(t, err)
+	html := buf.String()
+
+	assert.Contains(t, html, "<title>Staticgen report</title>")
+	assert.Contains(t, html, "Generated Jan 2, 2020 03:04:05 UTC in 1s")
+	assert.Contains(t, html, "<strong>3</strong> resources")
+	assert.Contains(t, html, "<strong>1</strong> errors")
+	assert.Contains(t, html, "<strong>1</strong> redirects")
+	assert.Contains(t, html, "<td>http://localhost/missing</td><td>http://localhost/about</td><td class=\"num\">404</td>")
+	assert.Contains(t, html, "→ http://localhost/older<br>→ http://localhost/new")
+	assert.Contains(t, html, `<td class="num" data-value="2048">2.0 kB</td>`)
+	assert.Contains(t, html, `<tr><td>&lt; 10ms</td><td class="num">1</td><td><div class="bar" style="width: 33.3%"></div></td></tr>`)
+	assert.Contains(t, html, `<tr><td>&lt; 100ms</td><td class="num">1</td>`)
+	assert.Contains(t, html, `<tr><td>≥ 2.5s</td><td class="num">1</td>`)
+	assert.False(t, strings.Contains(html, "<th>Site</th>"), "site column")
+	assert.False(t, strings.Contains(html, "ZgotmplZ"), "escaping")
+}
@@ -0,0 +1,141 @@
+// Package stats provides summary statistics of crawled resources.
+package stats
+
+import (
+	"encoding/json"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration encoded in JSON as milliseconds.
+type Duration time.Duration
+
+// MarshalJSON implementation.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(d) / float64(time.Millisecond))
+}
+
+// String implementation.
+func (d Duration) String() string {
+	return time.Duration(d).Round(time.Millisecond).String()
+}
+
+// Resource is a crawled resource.
+type Resource struct {
+	URL         string   `json:"url"`
+	StatusCode  int      `json:"status_code"`
+	ContentType string   `json:"content_type"`
+	Size        int64    `json:"size"`
+	Duration    Duration `json:"duration_ms"`
+	Error       bool     `json:"error"`
+}
+
+// Latency is a summary of response latency percentiles.
+type Latency struct {
+	P50 Duration `json:"p50_ms"`
+	P95 Duration `json:"p95_ms"`
+	P99 Duration `json:"p99_ms"`
+}
+
+// Summary is a summary of crawled resources.
+type Summary struct {
+	Resources    int              `json:"resources"`
+	Errors       int              `json:"errors"`
+	Bytes        int64            `json:"bytes"`
+	Status       map[string]int   `json:"status"`
+	ContentTypes map[string]int64 `json:"content_types"`
+	Latency      Latency          `json:"latency"`
+	Slowest      []Resource       `json:"slowest"`
+	Largest      []Resource       `json:"largest"`
+}
+
+// Summarize the given resources, including the n slowest and largest.
+// Only resources which received a response are included in the status
+// distribution and latency, and bytes are counted for resources without
+// an error, by media type.
+func Summarize(resources []Resource, n int) Summary {
+	s := Summary{
+		Resources:    len(resources),
+		Status:       make(map[string]int),
+		ContentTypes: make(map[string]int64),
+		Slowest:      []Resource{},
+		Largest:      []Resource{},
+	}
+
+	var durations []time.Duration
+	var responses, written []Resource
+
+	for _, r := range resources {
+		if r.Error {
+			s.Errors++
+		}
+
+		if r.StatusCode != 0 {
+			s.Status[strconv.Itoa(r.StatusCode)]++
+			durations = append(durations, time.Duration(r.Duration))
+			responses = append(responses, r)
+		}
+
+		if !r.Error {
+			s.Bytes += r.Size
+			s.ContentTypes[mediaType(r.ContentType)] += r.Size
+			written = append(written, r)
+		}
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	s.Latency = Latency{
+		P50: Duration(percentile(durations, 50)),
+		P95: Duration(percentile(durations, 95)),
+		P99: Duration(percentile(durations, 99)),
+	}
+
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].Duration > responses[j].Duration
+	})
+	s.Slowest = append(s.Slowest, top(responses, n)...)
+
+	sort.SliceStable(written, func(i, j int) bool {
+		return written[i].Size > written[j].Size
+	})
+	s.Largest = append(s.Largest, top(written, n)...)
+
+	return s
+}
+
+// percentile returns the p-th percentile of sorted durations, using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return sorted[i]
+}
+
+// top returns the first n resources.
+func top(resources []Resource, n int) []Resource {
+	if len(resources) > n {
+		return resources[:n]
+	}
+	return resources
+}
+
+// mediaType returns the media type of a content type, without parameters.
+func mediaType(s string) string {
+	t, _, err := mime.ParseMediaType(s)
+	if err != nil || t == "" {
+		return "unknown"
+	}
+	return t
+}
@@ -0,0 +1,56 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen/internal/stats"
+)
+
+// ms returns a duration in milliseconds.
+func ms(n int) stats.Duration {
+	return stats.Duration(time.Duration(n) * time.Millisecond)
+}
+
+// Test summarizing resources.
+func TestSummarize(t *testing.T) {
+	resources := []stats.Resource{
+		{URL: "/", StatusCode: 200, ContentType: "text/html; charset=utf-8", Size: 100, Duration: ms(10)},
+		{URL: "/about", StatusCode: 200, ContentType: "text/html", Size: 300, Duration: ms(30)},
+		{URL: "/style.css", StatusCode: 200, ContentType: "text/css", Size: 50, Duration: ms(5)},
+		{URL: "/logo.png", StatusCode: 200, Size: 1000, Duration: ms(20)},
+		{URL: "/missing", StatusCode: 404, ContentType: "text/html", Size: 10, Duration: ms(40), Error: true},
+		{URL: "/down", Error: true},
+	}
+
+	s := stats.Summarize(resources, 2)
+
+	assert.Equal(t, 6, s.Resources)
+	assert.Equal(t, 2, s.Errors)
+	assert.Equal(t, int64(1450), s.Bytes)
+	assert.Equal(t, map[string]int{"200": 4, "404": 1}, s.Status)
+	assert.Equal(t, map[string]int64{"text/html": 400, "text/css": 50, "unknown": 1000}, s.ContentTypes)
+	assert.Equal(t, stats.Latency{P50: ms(20), P95: ms(40), P99: ms(40)}, s.Latency)
+
+	assert.Len(t, s.Slowest, 2)
+	assert.Equal(t, "/missing", s.Slowest[0].URL)
+	assert.Equal(t, "/about", s.Slowest[1].URL)
+
+	assert.Len(t, s.Largest, 2)
+	assert.Equal(t, "/logo.png", s.Largest[0].URL)
+	assert.Equal(t, "/about", s.Largest[1].URL)
+}
+
+// Test summarizing nothing.
+func TestSummarize_empty(t *testing.T) {
+	s := stats.Summarize(nil, 5)
+	assert.Equal(t, 0, s.Resources)
+	assert.Equal(t, stats.Latency{}, s.Latency)
+
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"resources":0,"errors":0,"bytes":0,"status":{},"content_types":{},"latency":{"p50_ms":0,"p95_ms":0,"p99_ms":0},"slowest":[],"largest":[]}`, string(b))
+}
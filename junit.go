@@ -0,0 +1,152 @@
+package staticgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tj/staticgen/internal/junit"
+)
+
+// writeJUnit writes the JUnit report to the configured file, with a test
+// suite per path section of each site, and a failure for the build
+// error, if any.
+func (g *Generator) writeJUnit(buildErr error) error {
+	if g.JUnit == "" {
+		return nil
+	}
+
+	var suites []junit.TestSuite
+	for _, s := range g.generators {
+		suites = append(suites, s.testSuites()...)
+	}
+
+	if buildErr != nil {
+		suites = append(suites, junit.TestSuite{
+			Name: "staticgen",
+			TestCases: []junit.TestCase{
+				{
+					Name:      "build",
+					Classname: "staticgen",
+					Failure: &junit.Failure{
+						Message: buildErr.Error(),
+						Type:    "build",
+					},
+				},
+			},
+		})
+	}
+
+	err := os.MkdirAll(filepath.Dir(g.JUnit), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(g.JUnit)
+	if err != nil {
+		return err
+	}
+
+	err = junit.Write(f, "staticgen", suites)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// testSuites returns a test suite for each path section of the
+// resources visited, such as "/docs", with a test case per resource.
+func (g *Generator) testSuites() (suites []junit.TestSuite) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	seen := make(map[string]bool)
+	sections := make(map[string][]junit.TestCase)
+	for _, r := range g.results {
+		// distinct targets may resolve to the same url
+		if r.URL == nil || seen[r.URL.String()] {
+			continue
+		}
+		seen[r.URL.String()] = true
+
+		name := section(r.URL.Path)
+		if g.site != "" {
+			name = g.site + " " + name
+		}
+
+		c := junit.TestCase{
+			Name:      r.URL.String(),
+			Classname: name,
+			Time:      junit.Seconds(r.Duration.Seconds()),
+		}
+
+		if r.Error != nil {
+			c.Failure = failure(r)
+		}
+
+		sections[name] = append(sections[name], c)
+	}
+
+	var names []string
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cases := sections[name]
+		sort.SliceStable(cases, func(i, j int) bool {
+			return cases[i].Name < cases[j].Name
+		})
+
+		suites = append(suites, junit.TestSuite{
+			Name:      name,
+			TestCases: cases,
+		})
+	}
+
+	return
+}
+
+// failure returns the test case failure for a resource error, which is a
+// "broken-link" when the resource was linked from a page, or "status"
+// for an unaccepted response status, otherwise "request".
+func failure(r result) *junit.Failure {
+	f := &junit.Failure{
+		Message: r.Error.Error(),
+		Type:    "request",
+	}
+
+	if r.StatusCode != 0 {
+		f.Type = "status"
+	}
+
+	var details []string
+	if r.Parent != nil {
+		f.Type = "broken-link"
+		details = append(details, fmt.Sprintf("linked from %s", r.Parent))
+	}
+
+	if r.StatusCode != 0 {
+		details = append(details, fmt.Sprintf("responded with status %d", r.StatusCode))
+	}
+
+	if len(details) > 0 {
+		f.Text = capitalize(strings.Join(details, ", "))
+	}
+
+	return f
+}
+
+// section returns the first segment of path, or "/" for top-level paths.
+func section(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) < 2 {
+		return "/"
+	}
+	return "/" + parts[0]
+}
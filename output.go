@@ -0,0 +1,88 @@
+package staticgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// tailLines is the number of output lines retained for error reporting.
+const tailLines = 20
+
+// outputWriter is an io.Writer which splits command output into lines,
+// emitting each as an event, and retaining the tail for error reporting.
+type outputWriter struct {
+	name string
+	emit func(Event)
+	file io.Writer
+
+	mu   sync.Mutex
+	buf  []byte
+	tail []string
+}
+
+// Write implementation.
+func (w *outputWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		_, err := w.file.Write(b)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		w.line(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(b), nil
+}
+
+// Flush emits any remaining partial line.
+func (w *outputWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.line(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// Tail returns the last lines of output, or an empty string.
+func (w *outputWriter) Tail() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.tail) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n\n%s output:\n  %s", w.name, strings.Join(w.tail, "\n  "))
+}
+
+// line handles a single line of output.
+func (w *outputWriter) line(s string) {
+	s = strings.TrimSuffix(s, "\r")
+
+	w.tail = append(w.tail, s)
+	if len(w.tail) > tailLines {
+		w.tail = w.tail[1:]
+	}
+
+	w.emit(EventOutput{
+		Name: w.name,
+		Line: s,
+	})
+}
@@ -0,0 +1,187 @@
+package staticgen
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// progressInterval is the minimum interval between progress updates.
+const progressInterval = 100 * time.Millisecond
+
+// progressSlowest is the number of slowest requests displayed.
+const progressSlowest = 5
+
+// A ProgressReporter outputs a live view of crawling progress which
+// is updated in place, with errors output above it, intended for
+// terminals. See IsTerminal.
+type ProgressReporter struct {
+	// Writer is the output destination. Defaults to os.Stdout.
+	Writer io.Writer
+
+	site     string
+	url      string
+	count    int64
+	queued   int
+	errors   int64
+	bytes    int64
+	slowest  []EventVisitedResource
+	start    time.Time
+	rendered time.Time
+	lines    int
+	active   bool
+}
+
+// Report on the given event channel. Returns a channel which is
+// closed when the event channel is closed, and all reporting has
+// been completed.
+func (r *ProgressReporter) Report(ch <-chan Event) <-chan struct{} {
+	return forward(ch, r)
+}
+
+// Handle implementation.
+func (r *ProgressReporter) Handle(e Event) {
+	switch e := e.(type) {
+	case EventStartCrawl:
+		*r = ProgressReporter{
+			Writer: r.Writer,
+			site:   e.Site,
+			url:    e.URL,
+			start:  time.Now(),
+			active: true,
+		}
+		r.render()
+	case EventVisitedResource:
+		r.count++
+		r.queued = e.Queued
+		r.bytes += e.Size
+		r.track(e)
+
+		if e.Error != nil {
+			r.errors++
+			r.print("error: GET %s —— %s (%s)", e.URL, http.StatusText(e.StatusCode), e.Error)
+			return
+		}
+
+		if time.Since(r.rendered) >= progressInterval {
+			r.render()
+		}
+	case EventStoppedServer:
+		if e.Killed {
+			r.print("warning: %s did not exit in time, sent SIGKILL", label(e.Name))
+		}
+	case EventStopCrawl:
+		r.queued = 0
+		r.render()
+		r.lines = 0
+		r.active = false
+
+		for _, line := range summary(e.Stats) {
+			fmt.Fprintf(r.writer(), "  %s\n", line)
+		}
+	}
+}
+
+// Close implementation.
+func (r *ProgressReporter) Close() {}
+
+// IsTerminal returns true if f is a terminal, such as os.Stdout
+// when it has not been redirected to a file or pipe.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// track the slowest requests.
+func (r *ProgressReporter) track(e EventVisitedResource) {
+	r.slowest = append(r.slowest, e)
+	sort.SliceStable(r.slowest, func(i, j int) bool {
+		return r.slowest[i].Duration > r.slowest[j].Duration
+	})
+
+	if len(r.slowest) > progressSlowest {
+		r.slowest = r.slowest[:progressSlowest]
+	}
+}
+
+// print a line above the progress view.
+func (r *ProgressReporter) print(format string, v ...interface{}) {
+	r.clear()
+	fmt.Fprintf(r.writer(), format+"\n", v...)
+	r.lines = 0
+
+	if r.active {
+		r.render()
+	}
+}
+
+// render the progress view, replacing the previous one.
+func (r *ProgressReporter) render() {
+	r.rendered = time.Now()
+	elapsed := time.Since(r.start)
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(r.count) / elapsed.Seconds()
+	}
+
+	eta := "-"
+	if rate > 0 {
+		d := time.Duration(float64(r.queued) / rate * float64(time.Second))
+		eta = d.Round(time.Second).String()
+	}
+
+	var b strings.Builder
+
+	if r.site != "" {
+		fmt.Fprintf(&b, "Crawling site %q from %s\n", r.site, r.url)
+	} else {
+		fmt.Fprintf(&b, "Crawling %s\n", r.url)
+	}
+
+	fmt.Fprintf(&b, "  Done %s · Queued %s · Errors %s · %s\n",
+		humanize.Comma(r.count),
+		humanize.Comma(int64(r.queued)),
+		humanize.Comma(r.errors),
+		humanize.Bytes(uint64(r.bytes)))
+
+	fmt.Fprintf(&b, "  Throughput %.1f/s · Elapsed %s · ETA %s\n",
+		rate,
+		elapsed.Round(time.Millisecond),
+		eta)
+
+	if len(r.slowest) > 0 {
+		fmt.Fprintf(&b, "  Slowest:\n")
+		for _, e := range r.slowest {
+			fmt.Fprintf(&b, "    %8s  %s\n", e.Duration.Round(time.Millisecond), e.URL)
+		}
+	}
+
+	r.clear()
+	fmt.Fprint(r.writer(), b.String())
+	r.lines = strings.Count(b.String(), "\n")
+}
+
+// clear the previous progress view.
+func (r *ProgressReporter) clear() {
+	if r.lines > 0 {
+		fmt.Fprintf(r.writer(), "\033[%dA\033[J", r.lines)
+	}
+}
+
+// writer returns the output writer.
+func (r *ProgressReporter) writer() io.Writer {
+	if r.Writer == nil {
+		return os.Stdout
+	}
+	return r.Writer
+}
@@ -1,8 +1,17 @@
 package staticgen
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/apex/log"
 	"github.com/dustin/go-humanize"
@@ -18,33 +27,218 @@ type Reporter struct {
 // closed when the event channel is closed, and all reporting has
 // been completed.
 func (r *Reporter) Report(ch <-chan Event) <-chan struct{} {
+	return forward(ch, r)
+}
+
+// Handle implementation.
+func (r *Reporter) Handle(e Event) {
+	switch e := e.(type) {
+	case EventStartCrawl:
+		r.start = time.Now()
+		r.count = 0
+		if e.Site != "" {
+			log.Infof("Generating site %q from %s", e.Site, e.URL)
+		}
+	case EventStartingServer:
+		log.Infof("Starting %s with command %q", label(e.Name), e.Command)
+		if e.URL != "" {
+			log.Infof("Waiting for %s to listen on %s", label(e.Name), e.URL)
+		}
+	case EventStartedServer:
+		if e.URL != "" {
+			log.Infof("%s is listening for requests", capitalize(label(e.Name)))
+		}
+	case EventRunningHook:
+		log.Infof("Running %s hook %q", e.Name, e.Command)
+	case EventOutput:
+		log.Infof("%s | %s", e.Name, e.Line)
+	case EventStoppingServer:
+		if e.Command != "" {
+			log.Infof("Stopping %s with command %q", label(e.Name), e.Command)
+		} else {
+			log.Infof("Stopping %s, sending %s", label(e.Name), e.Signal)
+		}
+	case EventStoppedServer:
+		switch {
+		case e.Killed:
+			log.Warnf("%s did not exit in time, sent SIGKILL", capitalize(label(e.Name)))
+		case e.Signal != "":
+			log.Infof("%s exited on signal %q", capitalize(label(e.Name)), e.Signal)
+		default:
+			log.Infof("%s exited with status %d", capitalize(label(e.Name)), e.ExitCode)
+		}
+	case EventVisitedResource:
+		r.count++
+		if e.Error == nil && e.Filename == "" {
+			log.Infof("GET %s —— %s (%s)", e.URL, http.StatusText(e.StatusCode), e.Duration.Round(time.Millisecond))
+		} else if e.Error == nil {
+			log.Infof("GET %s —— %s —— %s (%s)", e.URL, e.Filename, http.StatusText(e.StatusCode), e.Duration.Round(time.Millisecond))
+		} else {
+			log.Errorf("GET %s —— %s (error: %s)", e.URL, http.StatusText(e.StatusCode), e.Error)
+		}
+	case EventCopiedFile:
+		log.Infof("COPY %s —— %s", e.Source, e.Filename)
+	case EventStopCrawl:
+		if e.Site != "" {
+			log.Infof("Completed site %q with %s resources in %s", e.Site, humanize.Comma(r.count), time.Since(r.start).Round(time.Millisecond))
+		} else {
+			log.Infof("Completed %s resources in %s", humanize.Comma(r.count), time.Since(r.start).Round(time.Millisecond))
+		}
+
+		for _, line := range summary(e.Stats) {
+			log.Infof("  %s", line)
+		}
+	}
+}
+
+// Close implementation.
+func (r *Reporter) Close() {}
+
+// A JSONReporter outputs events as newline-delimited JSON objects,
+// with their "type", such as "visited_resource", and "time".
+type JSONReporter struct {
+	// Writer is the output destination. Defaults to os.Stdout.
+	Writer io.Writer
+}
+
+// Report on the given event channel. Returns a channel which is
+// closed when the event channel is closed, and all reporting has
+// been completed.
+func (r *JSONReporter) Report(ch <-chan Event) <-chan struct{} {
+	return forward(ch, r)
+}
+
+// Handle implementation.
+func (r *JSONReporter) Handle(e Event) {
+	w := r.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+
+	b, err := marshalEvent(e, time.Now())
+	if err != nil {
+		log.WithError(err).Error("marshaling event")
+		return
+	}
+
+	_, err = w.Write(b)
+	if err != nil {
+		log.WithError(err).Error("writing event")
+	}
+}
+
+// Close implementation.
+func (r *JSONReporter) Close() {}
+
+// marshalEvent returns the JSON line for an event, including its type and time.
+func marshalEvent(e Event, t time.Time) ([]byte, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{"type":%q,"time":%q`, eventType(e), t.Format(time.RFC3339Nano))
+	if len(b) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(b[1:])
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// eventType returns the type of an event in snake case,
+// for example "visited_resource" for EventVisitedResource.
+func eventType(e Event) string {
+	name := strings.TrimPrefix(reflect.TypeOf(e).Name(), "Event")
+
+	var buf strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+// summary returns lines summarizing the stats.
+func summary(s Stats) (lines []string) {
+	var codes []string
+	for code := range s.Status {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var status []string
+	for _, code := range codes {
+		status = append(status, fmt.Sprintf("%s × %s", code, humanize.Comma(int64(s.Status[code]))))
+	}
+
+	var types []string
+	for t := range s.ContentTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return s.ContentTypes[types[i]] > s.ContentTypes[types[j]]
+	})
+
+	var bytes []string
+	for _, t := range types {
+		bytes = append(bytes, fmt.Sprintf("%s %s", t, humanize.Bytes(uint64(s.ContentTypes[t]))))
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("Status: %s", strings.Join(status, ", ")),
+		fmt.Sprintf("Bytes: %s (%s)", humanize.Bytes(uint64(s.Bytes)), strings.Join(bytes, ", ")),
+		fmt.Sprintf("Latency: p50 %s, p95 %s, p99 %s", s.Latency.P50, s.Latency.P95, s.Latency.P99),
+		fmt.Sprintf("Errors: %s", humanize.Comma(int64(s.Errors))))
+
+	if len(s.Slowest) > 0 {
+		lines = append(lines, "Slowest:")
+		for _, r := range s.Slowest {
+			lines = append(lines, fmt.Sprintf("  %8s  %s", r.Duration, r.URL))
+		}
+	}
+
+	if len(s.Largest) > 0 {
+		lines = append(lines, "Largest:")
+		for _, r := range s.Largest {
+			lines = append(lines, fmt.Sprintf("  %8s  %s", humanize.Bytes(uint64(r.Size)), r.URL))
+		}
+	}
+
+	return
+}
+
+// forward passes events from ch to the subscriber until ch is closed.
+func forward(ch <-chan Event, s Subscriber) <-chan struct{} {
 	done := make(chan struct{})
 
 	go func() {
 		defer close(done)
 		for e := range ch {
-			switch e := e.(type) {
-			case EventStartCrawl:
-				r.start = time.Now()
-			case EventStartingServer:
-				log.Infof("Starting server with command %q", e.Command)
-				log.Infof("Waiting for server to listen on %s", e.URL)
-			case EventStartedServer:
-				log.Infof("Server is listening for requests")
-			case EventStoppingServer:
-				log.Infof("Stopping server, sending SIGTERM")
-			case EventVisitedResource:
-				r.count++
-				if e.Error == nil {
-					log.Infof("GET %s —— %s —— %s (%s)", e.URL, e.Filename, http.StatusText(e.StatusCode), e.Duration.Round(time.Millisecond))
-				} else {
-					log.Errorf("GET %s —— %s (error: %s)", e.URL, http.StatusText(e.StatusCode), e.Error)
-				}
-			case EventStopCrawl:
-				log.Infof("Completed %s resources in %s", humanize.Comma(r.count), time.Since(r.start).Round(time.Millisecond))
-			}
+			s.Handle(e)
 		}
+		s.Close()
 	}()
 
 	return done
 }
+
+// label returns a human-friendly label for the named service.
+func label(name string) string {
+	if name == "server" {
+		return name
+	}
+	return fmt.Sprintf("service %q", name)
+}
+
+// capitalize returns s with the first letter in upper case.
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,76 @@
+package staticgen_test
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen"
+)
+
+// mustParse returns the parsed url.
+func mustParse(t testing.TB, s string) *url.URL {
+	u, err := url.Parse(s)
+	assert.NoError(t, err)
+	return u
+}
+
+// Test marshaling events.
+func TestMarshalEvent(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("visited resource", func(t *testing.T) {
+		b, err := staticgen.MarshalEvent(staticgen.EventVisitedResource{
+			Target: staticgen.Target{
+				Parent: mustParse(t, "http://example.com"),
+				URL:    mustParse(t, "http://example.com/about"),
+			},
+			Duration:    1500 * time.Microsecond,
+			StatusCode:  200,
+			ContentType: "text/html",
+			Size:        512,
+			Queued:      3,
+		}, at)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"visited_resource","time":"2020-01-02T03:04:05Z","url":"http://example.com/about","parent":"http://example.com","duration_ms":1.5,"status_code":200,"content_type":"text/html","size":512,"queued":3}`+"\n", string(b))
+	})
+
+	t.Run("start crawl", func(t *testing.T) {
+		b, err := staticgen.MarshalEvent(staticgen.EventStartCrawl{
+			URL: "http://example.com",
+		}, at)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"start_crawl","time":"2020-01-02T03:04:05Z","site":"","url":"http://example.com"}`+"\n", string(b))
+	})
+
+	t.Run("without fields", func(t *testing.T) {
+		b, err := staticgen.MarshalEvent(staticgen.EventEmpty{}, at)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"empty","time":"2020-01-02T03:04:05Z"}`+"\n", string(b))
+	})
+}
+
+// Test the JSON reporter output.
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := &staticgen.JSONReporter{Writer: &buf}
+
+	ch := make(chan staticgen.Event, 2)
+	ch <- staticgen.EventStartCrawl{URL: "http://example.com"}
+	ch <- staticgen.EventCopiedFile{Source: "public/app.css", Filename: "build/app.css"}
+	close(ch)
+	<-r.Report(ch)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], `{"type":"start_crawl","time":"`), lines[0])
+	assert.True(t, strings.HasSuffix(lines[0], `","site":"","url":"http://example.com"}`), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], `{"type":"copied_file","time":"`), lines[1])
+	assert.True(t, strings.HasSuffix(lines[1], `","source":"public/app.css","filename":"build/app.css"}`), lines[1])
+}
@@ -0,0 +1,506 @@
+package staticgen
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tj/staticgen/internal/dotenv"
+	"github.com/tj/staticgen/internal/probe"
+)
+
+// process is a running service command.
+type process struct {
+	Service
+	cmd     *exec.Cmd
+	out     *outputWriter
+	exited  chan struct{}
+	exitErr error
+}
+
+// startServices starts the configured services in dependency order,
+// followed by the server command, which depends on all of them.
+func (g *Generator) startServices(ctx context.Context) error {
+	services, err := sortServices(g.Services)
+	if err != nil {
+		return err
+	}
+
+	if g.Command != "" {
+		services = append(services, g.server())
+	}
+
+	for _, s := range services {
+		p, err := g.startService(ctx, s)
+		if err != nil {
+			_ = g.stopServices()
+			return fmt.Errorf("%s: %w", s.Name, err)
+		}
+
+		g.processes = append(g.processes, p)
+	}
+
+	return nil
+}
+
+// stopServices stops the running services in reverse order.
+func (g *Generator) stopServices() error {
+	var first error
+
+	for i := len(g.processes) - 1; i >= 0; i-- {
+		p := g.processes[i]
+		err := g.stopService(p)
+		if err != nil && first == nil {
+			first = fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+
+	g.processes = nil
+	return first
+}
+
+// server returns the service for the server command.
+func (g *Generator) server() Service {
+	return Service{
+		Name:        "server",
+		Command:     g.Command,
+		Env:         g.Env,
+		EnvFile:     g.EnvFile,
+		Cwd:         g.Cwd,
+		URL:         g.URL,
+		Socket:      g.Socket,
+		Ready:       g.Ready,
+		Log:         g.Log,
+		StopSignal:  g.StopSignal,
+		StopTimeout: g.StopTimeout,
+		StopCommand: g.StopCommand,
+	}
+}
+
+// startService starts a service command and waits for it to become ready.
+func (g *Generator) startService(ctx context.Context, s Service) (*process, error) {
+	g.emit(EventStartingServer{
+		Name:    s.Name,
+		Command: s.Command,
+		URL:     s.URL,
+	})
+
+	// start
+	p := &process{
+		Service: s,
+		cmd:     exec.Command("sh", "-c", s.Command),
+		out: &outputWriter{
+			name: s.Name,
+			emit: g.emit,
+		},
+		exited: make(chan struct{}),
+	}
+
+	env, err := g.env(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cmd.Env = env
+	p.cmd.Dir = s.Cwd
+	p.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	// log file
+	var logFile *os.File
+	if s.Log != "" {
+		f, err := os.OpenFile(s.Log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("opening log file: %w", err)
+		}
+		logFile = f
+		p.out.file = f
+	}
+
+	p.cmd.Stdout = p.out
+	p.cmd.Stderr = p.out
+	err = p.cmd.Start()
+	if err != nil {
+		if logFile != nil {
+			logFile.Close()
+		}
+		return nil, err
+	}
+
+	// wait for exit
+	go func() {
+		p.exitErr = p.cmd.Wait()
+		p.out.Flush()
+		if logFile != nil {
+			logFile.Close()
+		}
+		close(p.exited)
+	}()
+
+	// wait
+	err = g.waitForService(ctx, p)
+	if err != nil {
+		select {
+		case <-p.exited:
+			return nil, fmt.Errorf("exited before becoming ready: %v%s", p.exitErr, p.out.Tail())
+		default:
+			p.kill()
+			return nil, fmt.Errorf("waiting to start: %w%s", err, p.out.Tail())
+		}
+	}
+
+	g.emit(EventStartedServer{
+		Name:    s.Name,
+		Command: s.Command,
+		URL:     s.URL,
+	})
+
+	return p, nil
+}
+
+// waitForService waits for the service readiness probe to pass, or for
+// the process to exit. Services without a URL or socket are not probed.
+func (g *Generator) waitForService(ctx context.Context, p *process) error {
+	if p.URL == "" && p.Socket == "" {
+		return nil
+	}
+
+	client := g.HTTPClient
+	if p.Socket != "" {
+		client = socketClient(g.HTTPClient, p.Socket)
+	}
+
+	rawurl := p.URL
+	if rawurl == "" {
+		rawurl = socketHost
+	}
+
+	u, err := readyURL(rawurl, p.Ready.Path)
+	if err != nil {
+		return err
+	}
+
+	pr := probe.Probe{
+		URL:        u,
+		Method:     p.Ready.Method,
+		Status:     p.Ready.Status,
+		Body:       p.Ready.Body,
+		TCP:        p.Ready.TCP,
+		Socket:     p.Socket,
+		Interval:   time.Duration(p.Ready.Interval),
+		Timeout:    time.Duration(p.Ready.Timeout),
+		HTTPClient: client,
+	}
+
+	// stop waiting if the process exits
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return pr.Wait(ctx)
+}
+
+// readyURL returns the readiness probe URL, with the path resolved relative to rawurl.
+func readyURL(rawurl, path string) (*url.URL, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("parsing url: %w", err)
+	}
+
+	if path == "" {
+		return u, nil
+	}
+
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ready path: %w", err)
+	}
+
+	return u.ResolveReference(ref), nil
+}
+
+// stopService stops a service, sending the stop signal or running
+// the stop command, then sending SIGKILL if the process has not
+// exited after the stop timeout.
+func (g *Generator) stopService(p *process) error {
+	g.emit(EventStoppingServer{
+		Name:    p.Name,
+		Signal:  p.StopSignal,
+		Command: p.StopCommand,
+	})
+
+	select {
+	case <-p.exited:
+		g.emit(stoppedEvent(p, false))
+		return nil
+	default:
+	}
+
+	if p.StopCommand != "" {
+		err := g.runStopCommand(p)
+		if err != nil {
+			p.kill()
+			return fmt.Errorf("running stop command: %w", err)
+		}
+	} else {
+		sig, err := parseSignal(p.StopSignal)
+		if err != nil {
+			p.kill()
+			return err
+		}
+
+		err = syscall.Kill(-p.cmd.Process.Pid, sig)
+		if err != nil {
+			p.kill()
+			return fmt.Errorf("kill: %w", err)
+		}
+	}
+
+	killed := false
+	select {
+	case <-p.exited:
+	case <-time.After(time.Duration(p.StopTimeout)):
+		killed = true
+		p.kill()
+	}
+
+	g.emit(stoppedEvent(p, killed))
+	return nil
+}
+
+// runStopCommand runs the stop command of a service to completion, within
+// the stop timeout, and independent of the build context so that services
+// are stopped gracefully after a cancellation.
+func (g *Generator) runStopCommand(p *process) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.StopTimeout))
+	defer cancel()
+
+	out := &outputWriter{
+		name: p.Name,
+		emit: g.emit,
+	}
+
+	env, err := g.env(p.Service)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.StopCommand)
+	cmd.Env = env
+	cmd.Dir = p.Cwd
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	out.Flush()
+	if err != nil {
+		return fmt.Errorf("%w%s", err, out.Tail())
+	}
+
+	return nil
+}
+
+// env returns the environment for a service command, consisting of the
+// current environment, variables set by staticgen, the env file, and
+// finally the env map, which may reference the previous variables.
+func (g *Generator) env(s Service) ([]string, error) {
+	vars := make(map[string]string)
+	env := os.Environ()
+
+	set := func(key, value string) {
+		vars[key] = value
+		env = append(env, key+"="+value)
+	}
+
+	getenv := func(key string) string {
+		if v, ok := vars[key]; ok {
+			return v
+		}
+		return os.Getenv(key)
+	}
+
+	set("STATICGEN", "1")
+	set("STATICGEN_URL", g.URL)
+	if s.Name == "server" && g.port != "" {
+		set("PORT", g.port)
+	}
+
+	if s.Socket != "" {
+		set("STATICGEN_SOCKET", s.Socket)
+	}
+
+	if s.EnvFile != "" {
+		file, err := dotenv.Load(s.EnvFile, getenv)
+		if err != nil {
+			return nil, fmt.Errorf("loading env file: %w", err)
+		}
+
+		for _, v := range file {
+			set(v.Key, v.Value)
+		}
+	}
+
+	var keys []string
+	for k := range s.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	expanded := make(map[string]string)
+	for _, k := range keys {
+		expanded[k] = os.Expand(s.Env[k], getenv)
+	}
+
+	for _, k := range keys {
+		set(k, expanded[k])
+	}
+
+	return env, nil
+}
+
+// allocatePort picks an unused port and substitutes it into the URL.
+func (g *Generator) allocatePort() error {
+	u, err := url.Parse(g.URL)
+	if err != nil {
+		return fmt.Errorf("parsing url: %w", err)
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort(u.Hostname(), "0"))
+	if err != nil {
+		return err
+	}
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	err = l.Close()
+	if err != nil {
+		return err
+	}
+
+	u.Host = net.JoinHostPort(u.Hostname(), port)
+	g.URL = u.String()
+	g.port = port
+	return nil
+}
+
+// kill sends SIGKILL to the process group and waits for it to exit.
+func (p *process) kill() {
+	_ = syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL)
+	<-p.exited
+}
+
+// sortServices returns the services sorted so that each service
+// follows its dependencies, otherwise preserving their order.
+func sortServices(services []Service) ([]Service, error) {
+	byName := make(map[string]Service)
+	for _, s := range services {
+		if s.Name == "" {
+			return nil, fmt.Errorf("service with command %q is missing a name", s.Command)
+		}
+
+		if s.Name == "server" {
+			return nil, fmt.Errorf(`service name "server" is reserved for the server command`)
+		}
+
+		if _, ok := byName[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate service %q", s.Name)
+		}
+
+		byName[s.Name] = s
+	}
+
+	var sorted []Service
+	state := make(map[string]int)
+
+	var visit func(s Service, path []string) error
+	visit = func(s Service, path []string) error {
+		switch state[s.Name] {
+		case 1:
+			return fmt.Errorf("service dependency cycle: %s", strings.Join(append(path, s.Name), " -> "))
+		case 2:
+			return nil
+		}
+
+		state[s.Name] = 1
+		for _, name := range s.DependsOn {
+			d, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("service %q depends on unknown service %q", s.Name, name)
+			}
+
+			err := visit(d, append(path, s.Name))
+			if err != nil {
+				return err
+			}
+		}
+		state[s.Name] = 2
+
+		sorted = append(sorted, s)
+		return nil
+	}
+
+	for _, s := range services {
+		err := visit(s, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// signals is a map of supported stop signals.
+var signals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// parseSignal returns the signal by name, with or without the "SIG" prefix.
+func parseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig, ok := signals[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported signal %q", name)
+	}
+
+	return sig, nil
+}
+
+// stoppedEvent returns an event describing how the exited process stopped.
+func stoppedEvent(p *process, killed bool) EventStoppedServer {
+	e := EventStoppedServer{
+		Name:     p.Name,
+		Killed:   killed,
+		ExitCode: p.cmd.ProcessState.ExitCode(),
+	}
+
+	if ws, ok := p.cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		e.Signal = ws.Signal().String()
+	}
+
+	return e
+}
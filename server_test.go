@@ -0,0 +1,313 @@
+package staticgen_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen"
+)
+
+// Test sorting services by dependency.
+func TestSortServices(t *testing.T) {
+	cases := []struct {
+		name     string
+		services []staticgen.Service
+		order    []string
+		err      string
+	}{
+		{
+			name: "no dependencies",
+			services: []staticgen.Service{
+				{Name: "b"},
+				{Name: "a"},
+			},
+			order: []string{"b", "a"},
+		},
+		{
+			name: "dependencies",
+			services: []staticgen.Service{
+				{Name: "web", DependsOn: []string{"api"}},
+				{Name: "api", DependsOn: []string{"db", "cache"}},
+				{Name: "cache"},
+				{Name: "db"},
+			},
+			order: []string{"db", "cache", "api", "web"},
+		},
+		{
+			name: "cycle",
+			services: []staticgen.Service{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"c"}},
+				{Name: "c", DependsOn: []string{"a"}},
+			},
+			err: "service dependency cycle: a -> b -> c -> a",
+		},
+		{
+			name: "self dependency",
+			services: []staticgen.Service{
+				{Name: "a", DependsOn: []string{"a"}},
+			},
+			err: "service dependency cycle: a -> a",
+		},
+		{
+			name: "unknown dependency",
+			services: []staticgen.Service{
+				{Name: "api", DependsOn: []string{"db"}},
+			},
+			err: `service "api" depends on unknown service "db"`,
+		},
+		{
+			name: "duplicate",
+			services: []staticgen.Service{
+				{Name: "api"},
+				{Name: "api"},
+			},
+			err: `duplicate service "api"`,
+		},
+		{
+			name: "reserved name",
+			services: []staticgen.Service{
+				{Name: "server"},
+			},
+			err: `service name "server" is reserved for the server command`,
+		},
+		{
+			name: "missing name",
+			services: []staticgen.Service{
+				{Command: "./api"},
+			},
+			err: `service with command "./api" is missing a name`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			services, err := staticgen.SortServices(c.services)
+
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			var order []string
+			for _, s := range services {
+				order = append(order, s.Name)
+			}
+
+			assert.Equal(t, c.order, order)
+		})
+	}
+}
+
+// Test parsing stop signals.
+func TestParseSignal(t *testing.T) {
+	cases := []struct {
+		name   string
+		signal syscall.Signal
+		err    string
+	}{
+		{"SIGTERM", syscall.SIGTERM, ""},
+		{"TERM", syscall.SIGTERM, ""},
+		{"sigint", syscall.SIGINT, ""},
+		{"usr1", syscall.SIGUSR1, ""},
+		{"SIGFOO", 0, `unsupported signal "SIGFOO"`},
+		{"", 0, `unsupported signal "SIG"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sig, err := staticgen.ParseSignal(c.name)
+
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.signal, sig)
+		})
+	}
+}
+
+// recorder is a subscriber which records events.
+type recorder struct {
+	mu     sync.Mutex
+	events []staticgen.Event
+}
+
+// Handle implementation.
+func (r *recorder) Handle(e staticgen.Event) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// Close implementation.
+func (r *recorder) Close() {}
+
+// stopped returns the stopped events by service name.
+func (r *recorder) stopped() map[string]staticgen.EventStoppedServer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := make(map[string]staticgen.EventStoppedServer)
+	for _, e := range r.events {
+		if e, ok := e.(staticgen.EventStoppedServer); ok {
+			m[e.Name] = e
+		}
+	}
+	return m
+}
+
+// Test stopping services, sending SIGKILL after the stop timeout.
+func TestGenerator_Run_stopServices(t *testing.T) {
+	mux := http.NewServeMux()
+	g, dir := generator(t, mux, nil)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	trapped := filepath.Join(filepath.Dir(dir), "trapped")
+
+	// wait for the stubborn service to ignore SIGTERM before crawling
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 500; i++ {
+			if _, err := os.Stat(trapped); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		fmt.Fprintf(w, "Hello")
+	})
+
+	g.Services = []staticgen.Service{
+		{
+			Name:    "graceful",
+			Command: "sleep 60",
+		},
+		{
+			Name:        "stubborn",
+			Command:     `trap "" TERM; touch "$TRAPPED"; sleep 60`,
+			Env:         map[string]string{"TRAPPED": trapped},
+			StopTimeout: staticgen.Duration(100 * time.Millisecond),
+		},
+	}
+
+	var events recorder
+	g.Subscribe(&events)
+
+	err := g.Run(context.Background())
+	assert.NoError(t, err)
+
+	stopped := events.stopped()
+
+	e := stopped["graceful"]
+	assert.False(t, e.Killed, "killed")
+	assert.Equal(t, "terminated", e.Signal)
+
+	e = stopped["stubborn"]
+	assert.True(t, e.Killed, "killed")
+	assert.Equal(t, "killed", e.Signal)
+}
+
+// Test resolving the readiness probe URL.
+func TestReadyURL(t *testing.T) {
+	cases := []struct {
+		url  string
+		path string
+		want string
+	}{
+		{"http://127.0.0.1:3000", "", "http://127.0.0.1:3000"},
+		{"http://127.0.0.1:3000", "/health", "http://127.0.0.1:3000/health"},
+		{"http://127.0.0.1:3000/app/", "/health", "http://127.0.0.1:3000/health"},
+		{"http://127.0.0.1:3000/app/", "health", "http://127.0.0.1:3000/app/health"},
+		{"http://127.0.0.1:3000/app/", "health?full=1", "http://127.0.0.1:3000/app/health?full=1"},
+		{"http://localhost", "/ready", "http://localhost/ready"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url+" "+c.path, func(t *testing.T) {
+			u, err := staticgen.ReadyURL(c.url, c.path)
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, u.String())
+		})
+	}
+}
+
+// Test starting a server which never becomes ready.
+func TestGenerator_Run_notReady(t *testing.T) {
+	g, dir := generator(t, http.NewServeMux(), map[string]interface{}{
+		"url":     "http://127.0.0.1:1",
+		"command": "echo booting; sleep 60",
+		"ready": map[string]interface{}{
+			"tcp":      true,
+			"interval": "50ms",
+			"timeout":  "300ms",
+		},
+	})
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	err := g.Run(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server: waiting to start: context deadline exceeded (last error: ")
+	assert.Contains(t, err.Error(), "server output:\n  booting")
+}
+
+// Test starting a server which exits before becoming ready.
+func TestGenerator_Run_exited(t *testing.T) {
+	g, dir := generator(t, http.NewServeMux(), map[string]interface{}{
+		"url":     "http://127.0.0.1:1",
+		"command": "echo failed; exit 3",
+		"ready": map[string]interface{}{
+			"tcp":      true,
+			"interval": "50ms",
+			"timeout":  "5s",
+		},
+	})
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	err := g.Run(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server: exited before becoming ready: exit status 3")
+	assert.Contains(t, err.Error(), "server output:\n  failed")
+}
+
+// Test running stop commands after the build is cancelled.
+func TestGenerator_Run_stopCommand(t *testing.T) {
+	g, dir := generator(t, http.NewServeMux(), nil)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	stop := filepath.Join(filepath.Dir(dir), "stop")
+
+	g.Services = []staticgen.Service{
+		{
+			Name:        "api",
+			Command:     `while [ ! -f "$STOP" ]; do sleep 0.01; done`,
+			Env:         map[string]string{"STOP": stop},
+			StopCommand: `touch "$STOP"`,
+		},
+	}
+
+	var events recorder
+	g.Subscribe(&events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := g.Run(ctx)
+	assert.Error(t, err)
+
+	e, ok := events.stopped()["api"]
+	assert.True(t, ok, "stopped")
+	assert.False(t, e.Killed, "killed")
+	assert.Equal(t, 0, e.ExitCode)
+}
@@ -0,0 +1,52 @@
+package staticgen
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// socketHost is the logical host used for "unix://" URLs.
+const socketHost = "http://localhost"
+
+// parseSocketURL returns the socket path and logical URL for a URL in the
+// form "unix:///path/to.sock", otherwise the URL is returned unchanged.
+func parseSocketURL(s string) (socket, logical string, err error) {
+	if !strings.HasPrefix(s, "unix://") {
+		return "", s, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", err
+	}
+
+	return u.Path, socketHost, nil
+}
+
+// socketClient returns a copy of client, or the default client,
+// which dials the Unix domain socket at path for all requests.
+func socketClient(client *http.Client, path string) *http.Client {
+	c := http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	if client != nil {
+		c = *client
+	}
+
+	c.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     time.Minute,
+	}
+
+	return &c
+}
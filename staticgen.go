@@ -4,76 +4,196 @@ package staticgen
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/apex/log"
 
 	"github.com/tj/staticgen/internal/crawler"
+	"github.com/tj/staticgen/internal/fanout"
+	"github.com/tj/staticgen/internal/graph"
+	"github.com/tj/staticgen/internal/headers"
+	"github.com/tj/staticgen/internal/manifest"
+	"github.com/tj/staticgen/internal/stats"
 )
 
+// Stats is a summary of the resources visited while generating a site.
+type Stats = stats.Summary
+
+// statsTop is the number of slowest and largest resources in Stats.
+const statsTop = 5
+
 // Target is a target URL.
 type Target struct {
 	Parent *url.URL
 	URL    *url.URL
 }
 
+// result is the outcome of saving a crawled resource, or copying a
+// Source file, in which case the Target is empty.
+type result struct {
+	Target
+	Source     string
+	StatusCode int
+	Header     http.Header
+	Duration   time.Duration
+	Error      error
+	Filename   string
+	Size       int64
+	SHA256     string
+	Redirects  []*url.URL
+	Links      []*url.URL
+}
+
+// visited returns the event for a visited resource.
+func (r result) visited() EventVisitedResource {
+	return EventVisitedResource{
+		Target:      r.Target,
+		Duration:    r.Duration,
+		StatusCode:  r.StatusCode,
+		ContentType: r.Header.Get("Content-Type"),
+		Size:        r.Size,
+		Error:       r.Error,
+		Filename:    r.Filename,
+		Redirects:   r.Redirects,
+	}
+}
+
+// saved returns true if the resource was saved to disk.
+func (r result) saved() bool {
+	return r.Error == nil && r.Filename != ""
+}
+
+// manifestHeaders is a list of response headers included in the manifest.
+var manifestHeaders = []string{
+	"Cache-Control",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Type",
+	"ETag",
+	"Expires",
+	"Last-Modified",
+}
+
 // Generator is a static website generator.
 type Generator struct {
 	// Config used for crawling and producing the static website.
 	Config
 
+	// ConfigFile is the configuration file path, see Config.LoadProfile.
+	ConfigFile string
+
+	// Profile is the optional configuration profile name.
+	Profile string
+
+	// Overrides is an optional map of config keys to values, such as
+	// from command-line flags, taking precedence over all other
+	// configuration sources, see Config.LoadWith.
+	Overrides map[string]string
+
 	// HTTPClient ...
 	HTTPClient *http.Client
 
+	// JUnit is an optional file which a JUnit XML report is written to
+	// when Run completes, with a test case for each resource visited.
+	JUnit string
+
+	// Handler is an optional http.Handler which requests are dispatched
+	// to directly, without networking, allowing Go programs to generate
+	// a static website from their own handler.
+	Handler http.Handler
+
 	// crawler
 	crawler crawler.Crawler
 	wg      sync.WaitGroup
+	done    chan struct{}
+	err     error
+	site    string
 
-	// server command
-	cmd *exec.Cmd
-	out bytes.Buffer
+	// sites
+	generators []*Generator
+
+	// results
+	mu      sync.Mutex
+	results []result
+
+	// services
+	processes []*process
+	port      string
 
 	// events
-	events chan<- Event
+	events      *fanout.Fanout
+	subscribers []Subscriber
 }
 
 // Run starts the configured server command, starts to perform crawling,
 // and waits for completion before shutting down the configured server.
+// The after hook is run on completion, regardless of success, and
+// remaining events are delivered to subscribers before returning.
 func (g *Generator) Run(ctx context.Context) error {
+	defer g.closeEvents()
+
+	err := g.run(ctx)
+
+	if junitErr := g.writeJUnit(err); junitErr != nil && err == nil {
+		err = fmt.Errorf("writing junit report: %w", junitErr)
+	}
+
+	hookErr := g.runAfterHook(err)
+	if hookErr != nil && err == nil {
+		return fmt.Errorf("running after hook: %w", hookErr)
+	}
+
+	if hookErr != nil {
+		log.WithError(hookErr).Error("running after hook")
+	}
+
+	return err
+}
+
+// run performs the build.
+func (g *Generator) run(ctx context.Context) error {
 	err := g.Start(ctx)
 	if err != nil {
+		_ = g.stopServices()
 		return fmt.Errorf("starting: %w", err)
 	}
 
 	err = g.Wait()
 	if err != nil {
+		_ = g.stopServices()
 		return fmt.Errorf("waiting: %w", err)
 	}
 
-	defer g.emit(EventStopCrawl{})
-	if err := g.stopCommand(ctx); err != nil {
+	if err := g.stopServices(); err != nil {
 		return fmt.Errorf("stopping: %w", err)
 	}
 
 	return nil
 }
 
-// Start loads configuration from ./static.json, starts the
-// configured server, and begins the crawling process.
+// Start loads configuration from the ConfigFile, runs the before hook,
+// starts the configured server, and begins crawling each site in turn.
 func (g *Generator) Start(ctx context.Context) error {
 	// load configuration
-	err := g.Config.Load("static.json")
+	err := g.Config.LoadWith(LoadOptions{
+		Path:      g.ConfigFile,
+		Profile:   g.Profile,
+		Environ:   os.Environ(),
+		Overrides: g.Overrides,
+	})
 	if err != nil {
 		return fmt.Errorf("loading configuration: %w", err)
 	}
@@ -90,12 +210,126 @@ func (g *Generator) Start(ctx context.Context) error {
 		return fmt.Errorf("creating output directory: %w", err)
 	}
 
-	// start command
-	err = g.startCommand(ctx)
+	// free port
+	if g.FreePort {
+		err = g.allocatePort()
+		if err != nil {
+			return fmt.Errorf("allocating port: %w", err)
+		}
+	}
+
+	// before hook
+	err = g.runBeforeHook(ctx)
+	if err != nil {
+		return fmt.Errorf("running before hook: %w", err)
+	}
+
+	// start services
+	err = g.startServices(ctx)
+	if err != nil {
+		return fmt.Errorf("starting services: %w", err)
+	}
+
+	// unix domain socket
+	if g.Socket != "" {
+		g.HTTPClient = socketClient(g.HTTPClient, g.Socket)
+	}
+
+	// in-process handler
+	if g.Handler != nil {
+		g.HTTPClient = &http.Client{
+			Transport: crawler.HandlerTransport{Handler: g.Handler},
+		}
+	}
+
+	// sites
+	sites, err := g.sites()
+	if err != nil {
+		return err
+	}
+	g.generators = sites
+
+	// crawl each site in turn
+	g.done = make(chan struct{})
+	go func() {
+		defer close(g.done)
+		for _, s := range sites {
+			err := s.build(ctx)
+			if err != nil && s.site != "" {
+				err = fmt.Errorf("site %q: %w", s.site, err)
+			}
+
+			if err != nil {
+				g.err = err
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Wait for crawling to complete.
+func (g *Generator) Wait() error {
+	<-g.done
+	return g.err
+}
+
+// sites returns a generator for each configured site, sharing the
+// HTTP client and events, or the generator itself when there are none.
+func (g *Generator) sites() ([]*Generator, error) {
+	if len(g.Sites) == 0 {
+		return []*Generator{g}, nil
+	}
+
+	base, err := url.Parse(g.URL)
 	if err != nil {
-		return fmt.Errorf("starting command: %w", err)
+		return nil, fmt.Errorf("parsing url: %w", err)
+	}
+
+	var sites []*Generator
+	for _, s := range g.Sites {
+		u, err := url.Parse(s.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing site %q url: %w", s.Name, err)
+		}
+
+		c := g.Config
+		c.Sites = nil
+		c.URL = base.ResolveReference(u).String()
+		c.Dir = s.Dir
+
+		if s.Pages != nil {
+			c.Pages = s.Pages
+		}
+
+		if s.Status != nil {
+			c.Status = s.Status
+		}
+
+		if s.Exclude != nil {
+			c.Exclude = s.Exclude
+		}
+
+		err = os.RemoveAll(c.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("removing site %q output directory: %w", s.Name, err)
+		}
+
+		sites = append(sites, &Generator{
+			Config:     c,
+			HTTPClient: g.HTTPClient,
+			site:       s.Name,
+			events:     g.events,
+		})
 	}
 
+	return sites, nil
+}
+
+// build crawls the website, saving resources to disk, followed by the
+// error pages, copied files, graph, manifest and headers.
+func (g *Generator) build(ctx context.Context) error {
 	// parse url
 	u, err := url.Parse(g.URL)
 	if err != nil {
@@ -106,50 +340,95 @@ func (g *Generator) Start(ctx context.Context) error {
 	g.crawler = crawler.Crawler{
 		URL:         u,
 		Allow404:    g.Allow404,
+		Status:      g.statusPolicies(),
+		Exclude:     g.Exclude,
 		Concurrency: g.Concurrency,
 		HTTPClient:  g.HTTPClient,
 	}
 
+	// start crawling
+	crawlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g.emit(EventStartCrawl{Site: g.site, URL: g.URL})
+	err = g.crawler.Start(crawlCtx)
+	if err != nil {
+		return fmt.Errorf("starting crawler: %w", err)
+	}
+
 	// start workers
-	ctx, cancel := context.WithCancel(ctx)
 	for i := 0; i < g.Concurrency; i++ {
 		g.wg.Add(1)
 		go func() {
-			g.saveLoop(ctx)
+			g.saveLoop(crawlCtx)
 			g.wg.Done()
 		}()
 	}
 
-	// start crawling
-	g.emit(EventStartCrawl{})
-	err = g.crawler.Start(ctx)
-	if err != nil {
-		cancel()
-		return fmt.Errorf("starting crawler: %w", err)
-	}
-
 	// queue pages
 	g.queuePages(u)
 
 	// wait for crawling to complete,
 	// then exit the save loops.
-	go func() {
-		g.crawler.Wait()
-		cancel()
-	}()
+	err = g.crawler.Wait()
+	cancel()
+	g.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("crawling: %w", err)
+	}
 
-	return nil
-}
+	err = g.saveErrorPages(ctx)
+	if err != nil {
+		return fmt.Errorf("saving error pages: %w", err)
+	}
 
-// Wait for crawling to complete.
-func (g *Generator) Wait() error {
-	g.wg.Wait()
+	err = g.copyFiles()
+	if err != nil {
+		return fmt.Errorf("copying files: %w", err)
+	}
+
+	err = g.writeGraph()
+	if err != nil {
+		return fmt.Errorf("writing graph: %w", err)
+	}
+
+	err = g.writeManifest()
+	if err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+
+	err = g.writeHeaders()
+	if err != nil {
+		return fmt.Errorf("writing headers: %w", err)
+	}
+
+	summary := g.summarize()
+
+	err = g.writeStats(summary)
+	if err != nil {
+		return fmt.Errorf("writing stats: %w", err)
+	}
+
+	g.emit(EventStopCrawl{Site: g.site, Stats: summary})
 	return nil
 }
 
-// Report registers a channel for reporting on events.
+// Report registers a channel for reporting on events, see Subscribe.
 func (g *Generator) Report(ch chan<- Event) {
-	g.events = ch
+	g.Subscribe(channel(ch))
+}
+
+// statusPolicies returns the crawler status policies, sorted by pattern.
+func (g *Generator) statusPolicies() (policies []crawler.StatusPolicy) {
+	for _, pattern := range sortedKeys(g.Status) {
+		p := g.Status[pattern]
+		policies = append(policies, crawler.StatusPolicy{
+			Pattern: pattern,
+			Allow:   p.Allow,
+			Save:    p.Save,
+		})
+	}
+	return
 }
 
 // queuePages queues the configured Pages relative to the given url.
@@ -189,130 +468,383 @@ func (g *Generator) save(r crawler.Resource) error {
 		dst = filepath.Join(g.Dir, dir, file, "index.html")
 	}
 
-	g.emit(EventVisitedResource{
+	res := result{
 		Target:     Target(r.Target),
-		Duration:   r.Duration,
 		StatusCode: r.StatusCode,
+		Header:     r.Header,
+		Duration:   r.Duration,
 		Error:      r.Error,
 		Filename:   dst,
-	})
+		Redirects:  r.Redirects,
+		Links:      r.Links,
+	}
 
-	// request error, don't copy to disk
-	if r.Error != nil {
-		return nil
+	// discarded body, don't copy to disk
+	if r.Discard {
+		res.Filename = ""
+		r.Body.Close()
 	}
 
-	defer r.Body.Close()
-	return writeFile(r.Body, dst)
+	// copy to disk unless there was a request error
+	var err error
+	if r.Error == nil && !r.Discard {
+		h := sha256.New()
+		res.Size, err = writeFile(io.TeeReader(r.Body, h), dst)
+		res.SHA256 = hex.EncodeToString(h.Sum(nil))
+		r.Body.Close()
+	}
+
+	g.record(res)
+
+	e := res.visited()
+	e.Queued = g.crawler.Queued()
+	g.emit(e)
+
+	return err
 }
 
-// startCommand starts the configured server command.
-func (g *Generator) startCommand(ctx context.Context) error {
-	if g.Command == "" {
-		return nil
+// saveErrorPages saves the configured error pages to disk, regardless
+// of their response status code.
+func (g *Generator) saveErrorPages(ctx context.Context) error {
+	u, err := url.Parse(g.URL)
+	if err != nil {
+		return fmt.Errorf("parsing url: %w", err)
 	}
 
-	g.emit(EventStartingServer{
-		Command: g.Command,
-		URL:     g.URL,
-	})
+	var names []string
+	for name := range g.ErrorPages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := g.ErrorPages[name]
+		if p == "" {
+			p = fmt.Sprintf("/staticgen-not-found-%d", time.Now().UnixNano())
+		}
 
-	// start
-	g.cmd = exec.Command("sh", "-c", g.Command)
-	g.cmd.Env = append(os.Environ(), "STATICGEN=1")
-	g.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	g.cmd.Stdout = &g.out
-	g.cmd.Stderr = &g.out
-	err := g.cmd.Start()
+		t := *u
+		t.Path = p
+
+		err := g.saveErrorPage(ctx, &t, filepath.Join(g.Dir, name))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// saveErrorPage saves the page at u to the given file.
+func (g *Generator) saveErrorPage(ctx context.Context, u *url.URL, dst string) error {
+	start := time.Now()
+	res := result{
+		Target:   Target{URL: u},
+		Filename: dst,
+	}
+
+	defer func() {
+		g.record(res)
+		g.emit(res.visited())
+	}()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
+		res.Error = err
 		return err
 	}
 
-	// timeout
-	ctx, cancel := context.WithTimeout(ctx, time.Second*15)
-	defer cancel()
+	client := g.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
 
-	// wait
-	err = waitForListen(ctx, g.URL)
+	r, err := client.Do(req.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("waiting for app to start: %w", err)
+		res.Error = err
+		return err
 	}
+	defer r.Body.Close()
 
-	g.emit(EventStartedServer{
-		Command: g.Command,
-		URL:     g.URL,
-	})
+	res.StatusCode = r.StatusCode
+	res.Header = r.Header
+	res.Duration = time.Since(start)
+
+	h := sha256.New()
+	res.Size, res.Error = writeFile(io.TeeReader(r.Body, h), dst)
+	res.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return res.Error
+}
+
+// record a result for the outputs written on completion.
+func (g *Generator) record(r result) {
+	g.mu.Lock()
+	g.results = append(g.results, r)
+	g.mu.Unlock()
+}
+
+// writeGraph writes the link graph to the configured files.
+func (g *Generator) writeGraph() error {
+	if len(g.Graph) == 0 {
+		return nil
+	}
+
+	// node urls by link, as links to "/blog/"
+	// are crawled once as "/blog" and vice versa
+	urls := make(map[string]string)
+	for _, r := range g.results {
+		if r.URL != nil {
+			urls[linkKey(r.URL)] = r.URL.String()
+		}
+	}
+
+	var nodes []graph.Node
+	for _, r := range g.results {
+		if r.URL == nil {
+			continue
+		}
+
+		n := graph.Node{
+			URL:         r.URL.String(),
+			StatusCode:  r.StatusCode,
+			ContentType: r.Header.Get("Content-Type"),
+			Size:        r.Size,
+			Duration:    r.Duration,
+		}
+
+		for _, l := range r.Links {
+			u, ok := urls[linkKey(l)]
+			if !ok {
+				u = l.String()
+			}
+			n.Links = append(n.Links, u)
+		}
+
+		if r.Error != nil {
+			n.Error = r.Error.Error()
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	for _, name := range g.Graph {
+		var write func(io.Writer, []graph.Node) error
+
+		switch filepath.Ext(name) {
+		case ".json":
+			write = graph.WriteJSON
+		case ".dot", ".gv":
+			write = graph.WriteDOT
+		case ".csv":
+			write = graph.WriteCSV
+		default:
+			return fmt.Errorf("unsupported graph format %q", name)
+		}
+
+		err := g.writeOutput(name, func(w io.Writer) error {
+			return write(w, nodes)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-// stopCommand stops the configured command.
-func (g *Generator) stopCommand(ctx context.Context) error {
-	if g.Command == "" {
+// linkKey returns the url without a trailing slash for matching links.
+func linkKey(u *url.URL) string {
+	c := *u
+	c.Path = strings.TrimRight(c.Path, "/")
+	return c.String()
+}
+
+// writeManifest writes the manifest of saved files to the configured file.
+func (g *Generator) writeManifest() error {
+	if g.Manifest == "" {
 		return nil
 	}
 
-	g.emit(EventStoppingServer{})
+	var entries []manifest.Entry
+	seen := make(map[string]bool)
+	for _, r := range g.results {
+		if !r.saved() {
+			continue
+		}
+
+		path, err := filepath.Rel(g.Dir, r.Filename)
+		if err != nil {
+			return err
+		}
+
+		// distinct urls may be saved to the same file
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		e := manifest.Entry{
+			Source:      r.Source,
+			Path:        filepath.ToSlash(path),
+			ContentType: r.Header.Get("Content-Type"),
+			Size:        r.Size,
+			SHA256:      r.SHA256,
+			StatusCode:  r.StatusCode,
+			Headers:     make(map[string]string),
+		}
+
+		if r.URL != nil {
+			e.URL = r.URL.String()
+		}
+
+		for _, name := range append(manifestHeaders, g.Headers.Allow...) {
+			if v := r.Header.Get(name); v != "" {
+				e.Headers[http.CanonicalHeaderKey(name)] = v
+			}
+		}
 
-	pgid, err := syscall.Getpgid(g.cmd.Process.Pid)
+		entries = append(entries, e)
+	}
+
+	return g.writeOutput(g.Manifest, func(w io.Writer) error {
+		return manifest.Write(w, entries)
+	})
+}
+
+// summarize returns statistics of the resources visited.
+func (g *Generator) summarize() Stats {
+	var resources []stats.Resource
+	for _, r := range g.results {
+		if r.URL == nil {
+			continue
+		}
+
+		resources = append(resources, stats.Resource{
+			URL:         r.URL.String(),
+			StatusCode:  r.StatusCode,
+			ContentType: r.Header.Get("Content-Type"),
+			Size:        r.Size,
+			Duration:    stats.Duration(r.Duration),
+			Error:       r.Error != nil,
+		})
+	}
+
+	return stats.Summarize(resources, statsTop)
+}
+
+// writeStats writes the statistics to the configured file.
+func (g *Generator) writeStats(s Stats) error {
+	if g.Stats == "" {
+		return nil
+	}
+
+	return g.writeOutput(g.Stats, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	})
+}
+
+// writeHeaders writes the allowed response headers of saved files
+// in the configured format.
+func (g *Generator) writeHeaders() error {
+	if len(g.Headers.Allow) == 0 {
+		return nil
+	}
+
+	var write func(io.Writer, []headers.Rule) error
+
+	switch g.Headers.Format {
+	case "netlify":
+		write = headers.WriteNetlify
+	case "nginx":
+		write = headers.WriteNginx
+	case "s3":
+		write = headers.WriteS3
+	default:
+		return fmt.Errorf("unsupported format %q", g.Headers.Format)
+	}
+
+	var rules []headers.Rule
+	seen := make(map[string]bool)
+	for _, r := range g.results {
+		if !r.saved() || r.URL == nil {
+			continue
+		}
+
+		file, err := filepath.Rel(g.Dir, r.Filename)
+		if err != nil {
+			return err
+		}
+
+		// distinct urls may be saved to the same file
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		path := r.URL.Path
+		if path == "" {
+			path = "/"
+		}
+
+		rules = append(rules, headers.Rule{
+			Path:   path,
+			File:   filepath.ToSlash(file),
+			Header: headers.Filter(r.Header, g.Headers.Allow),
+		})
+	}
+
+	return g.writeOutput(g.Headers.File, func(w io.Writer) error {
+		return write(w, rules)
+	})
+}
+
+// writeOutput writes a build output file relative to the output directory.
+func (g *Generator) writeOutput(name string, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+
+	err := write(&buf)
 	if err != nil {
-		return fmt.Errorf("getting process group id: %w", err)
+		return fmt.Errorf("%s: %w", name, err)
 	}
 
-	err = syscall.Kill(-pgid, syscall.SIGTERM)
+	_, err = writeFile(&buf, filepath.Join(g.Dir, name))
 	if err != nil {
-		return fmt.Errorf("kill: %w", err)
+		return fmt.Errorf("%s: %w", name, err)
 	}
 
-	_ = g.cmd.Wait()
 	return nil
 }
 
 // emit an event.
 func (g *Generator) emit(e Event) {
 	if g.events != nil {
-		g.events <- e
+		g.events.Publish(e)
 	}
 }
 
-// writeFile writes to filename and ensures the directory exists.
-func writeFile(r io.Reader, filename string) error {
+// writeFile writes to filename and ensures the directory exists,
+// returning the number of bytes written.
+func writeFile(r io.Reader, filename string) (int64, error) {
 	dir := filepath.Dir(filename)
 
 	err := os.MkdirAll(dir, 0755)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	f, err := os.Create(filename)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err = io.Copy(f, r)
+	n, err := io.Copy(f, r)
 	if err != nil {
-		return err
+		f.Close()
+		return n, err
 	}
 
-	return f.Close()
-}
-
-// waitForListen blocks until `addr` is listening.
-func waitForListen(ctx context.Context, url string) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Second):
-			if isListening(url) {
-				return nil
-			}
-		}
-	}
-}
-
-// isListening returns true if there's an HTTP server listening on url.
-func isListening(url string) bool {
-	_, err := http.Head(url)
-	return err == nil
+	return n, f.Close()
 }
@@ -0,0 +1,175 @@
+package staticgen_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/tj/staticgen"
+)
+
+// generator returns a generator crawling h, with the given configuration
+// written to a temporary directory, and the output directory.
+func generator(t testing.TB, h http.Handler, config map[string]interface{}) (*staticgen.Generator, string) {
+	dir, err := ioutil.TempDir("", "staticgen")
+	assert.NoError(t, err)
+
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+
+	if _, ok := config["url"]; !ok {
+		config["url"] = "http://example.com"
+	}
+
+	if _, ok := config["dir"]; !ok {
+		config["dir"] = filepath.Join(dir, "build")
+	}
+
+	b, err := json.Marshal(config)
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "static.json")
+	err = ioutil.WriteFile(path, b, 0644)
+	assert.NoError(t, err)
+
+	g := &staticgen.Generator{
+		ConfigFile: path,
+		Handler:    h,
+	}
+
+	return g, config["dir"].(string)
+}
+
+// Test cancelling the context mid-crawl.
+func TestGenerator_Run_cancel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="/slow">Slow</a>`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	g, dir := generator(t, mux, nil)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "deadline exceeded")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the generator to stop")
+	}
+}
+
+// Test saving error pages.
+func TestGenerator_Run_errorPages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<a href="/about">About</a>`)
+		case "/about":
+			fmt.Fprintf(w, `About`)
+		case "/error":
+			http.Error(w, "Server error", http.StatusInternalServerError)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+	})
+
+	t.Run("saved", func(t *testing.T) {
+		g, dir := generator(t, mux, map[string]interface{}{
+			"error_pages": map[string]string{
+				"404.html": "",
+				"500.html": "/error",
+			},
+		})
+		defer os.RemoveAll(filepath.Dir(dir))
+
+		err := g.Run(context.Background())
+		assert.NoError(t, err)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "404.html"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Not found\n", string(b))
+
+		b, err = ioutil.ReadFile(filepath.Join(dir, "500.html"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Server error\n", string(b))
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		g, dir := generator(t, mux, map[string]interface{}{
+			"error_pages": map[string]string{
+				"404.html": "",
+				"about":    "/error",
+			},
+		})
+		defer os.RemoveAll(filepath.Dir(dir))
+
+		err := g.Run(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "saving error pages: about: ")
+	})
+}
+
+// Test writing the link graph.
+func TestGenerator_Run_graph(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<a href="/a">A</a><a href="/b">B</a>`)
+		case "/a":
+			fmt.Fprintf(w, `<a href="/b/">B</a>`)
+		case "/b":
+			fmt.Fprintf(w, `<a href="/">Home</a>`)
+		}
+	})
+
+	g, dir := generator(t, mux, map[string]interface{}{
+		"graph": []string{"graph.csv"},
+	})
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	err := g.Run(context.Background())
+	assert.NoError(t, err)
+
+	f, err := os.Open(filepath.Join(dir, "graph.csv"))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.NoError(t, err)
+
+	var edges [][]string
+	for _, row := range rows[1:] {
+		edges = append(edges, row[:2])
+	}
+
+	assert.Equal(t, [][]string{
+		{"http://example.com", "http://example.com/b"},
+		{"http://example.com/a", "http://example.com"},
+		{"http://example.com/b", "http://example.com"},
+		{"http://example.com/b", "http://example.com/a"},
+	}, edges)
+}
@@ -0,0 +1,64 @@
+package staticgen
+
+import (
+	"github.com/tj/staticgen/internal/fanout"
+)
+
+// eventBuffer is the number of events buffered for each subscriber.
+const eventBuffer = 1000
+
+// A Subscriber receives events from a Generator.
+type Subscriber interface {
+	// Handle is called with each event, in order, from a single goroutine.
+	Handle(Event)
+
+	// Close is called once all events have been handled.
+	Close()
+}
+
+// channel is a Subscriber which sends events to a channel.
+type channel chan<- Event
+
+// Handle implementation.
+func (c channel) Handle(e Event) {
+	c <- e
+}
+
+// Close implementation.
+func (c channel) Close() {}
+
+// Subscribe registers a subscriber for events. Events are buffered for
+// each subscriber, and dropped rather than blocking the build when it
+// falls behind, see Dropped. Subscribers are closed when Run returns.
+func (g *Generator) Subscribe(s Subscriber) {
+	if g.events == nil {
+		g.events = new(fanout.Fanout)
+	}
+
+	g.events.Subscribe(func(v interface{}) {
+		s.Handle(v.(Event))
+	}, eventBuffer)
+
+	g.subscribers = append(g.subscribers, s)
+}
+
+// Dropped returns the number of events dropped
+// because a subscriber did not keep up.
+func (g *Generator) Dropped() int64 {
+	if g.events == nil {
+		return 0
+	}
+	return g.events.Dropped()
+}
+
+// closeEvents delivers the remaining events and closes the subscribers.
+func (g *Generator) closeEvents() {
+	if g.events == nil {
+		return
+	}
+
+	g.events.Close()
+	for _, s := range g.subscribers {
+		s.Close()
+	}
+}
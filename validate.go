@@ -0,0 +1,181 @@
+package staticgen
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tj/staticgen/internal/glob"
+)
+
+// ValidationError is returned when the configuration is invalid,
+// listing all problems found.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implementation.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate returns a *ValidationError if the configuration is invalid.
+func (c *Config) Validate() error {
+	problems := c.problems()
+	if len(problems) > 0 {
+		return &ValidationError{
+			Problems: problems,
+		}
+	}
+	return nil
+}
+
+// problems returns a list of configuration problems.
+func (c *Config) problems() (problems []string) {
+	add := func(format string, v ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, v...))
+	}
+
+	// url
+	u, err := url.Parse(c.URL)
+	switch {
+	case err != nil:
+		add("url: %s", err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		add("url: scheme must be http, https or unix, got %q", u.Scheme)
+	case u.Host == "":
+		add("url: host is missing in %q", c.URL)
+	}
+
+	// concurrency
+	if c.Concurrency < 1 {
+		add("concurrency: must be at least 1, got %d", c.Concurrency)
+	}
+
+	if c.FreePort && c.Socket != "" {
+		add("free_port: cannot be used with a socket")
+	}
+
+	if c.FreePort && c.Command == "" {
+		add("free_port: requires a command")
+	}
+
+	// status policies
+	for _, pattern := range sortedKeys(c.Status) {
+		p := c.Status[pattern]
+		_, err := glob.Compile(pattern)
+		if err != nil {
+			add("status: %s", err)
+		}
+
+		for _, code := range p.Allow {
+			if code < 100 || code > 599 {
+				add("status: %q has invalid status code %d", pattern, code)
+			}
+		}
+	}
+
+	// exclude patterns
+	for _, pattern := range c.Exclude {
+		_, err := glob.Compile(pattern)
+		if err != nil {
+			add("exclude: %s", err)
+		}
+	}
+
+	// copy patterns
+	var patterns []string
+	for pattern := range c.Copy {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			add("copy: invalid pattern %q: %s", pattern, err)
+		}
+	}
+
+	// graph formats
+	for _, name := range c.Graph {
+		switch filepath.Ext(name) {
+		case ".json", ".dot", ".gv", ".csv":
+		default:
+			add("graph: unsupported format %q, must be .json, .dot or .csv", name)
+		}
+	}
+
+	// headers
+	switch c.Headers.Format {
+	case "netlify", "nginx", "s3":
+	default:
+		add("headers.format: must be netlify, nginx or s3, got %q", c.Headers.Format)
+	}
+
+	// services
+	_, err = parseSignal(c.StopSignal)
+	if err != nil {
+		add("stop_signal: %s", err)
+	}
+
+	for _, s := range c.Services {
+		_, err := parseSignal(s.StopSignal)
+		if err != nil {
+			add("services: %q stop_signal: %s", s.Name, err)
+		}
+
+		if s.Command == "" {
+			add("services: %q command is missing", s.Name)
+		}
+	}
+
+	_, err = sortServices(c.Services)
+	if err != nil {
+		add("services: %s", err)
+	}
+
+	// sites
+	names := make(map[string]bool)
+	for _, s := range c.Sites {
+		switch {
+		case s.Name == "":
+			add("sites: name is missing")
+		case names[s.Name]:
+			add("sites: duplicate name %q", s.Name)
+		}
+		names[s.Name] = true
+
+		_, err := url.Parse(s.URL)
+		if err != nil {
+			add("sites: %q url: %s", s.Name, err)
+		}
+
+		for _, pattern := range sortedKeys(s.Status) {
+			_, err := glob.Compile(pattern)
+			if err != nil {
+				add("sites: %q status: %s", s.Name, err)
+			}
+		}
+
+		for _, pattern := range s.Exclude {
+			_, err := glob.Compile(pattern)
+			if err != nil {
+				add("sites: %q exclude: %s", s.Name, err)
+			}
+		}
+	}
+
+	return
+}
+
+// sortedKeys returns the sorted keys of a status policy map.
+func sortedKeys(m map[string]StatusPolicy) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}